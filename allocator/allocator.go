@@ -1,21 +1,32 @@
 package allocator
 
 import (
-	"container/list"
+	"errors"
+	"fmt"
 	"math"
 
+	"github.com/bivguy/Comp412/cfg"
+	"github.com/bivguy/Comp412/ir"
 	m "github.com/bivguy/Comp412/models"
 )
 
+// ErrMultipleBlocks is returned by New for a program with branches: allocation across basic blocks is not supported,
+// even though the renamer gives the values that flow between blocks one VR each
+var ErrMultipleBlocks = errors.New("allocation across basic blocks is not supported")
+
 const RESERVEDREGISTER = 32768
 const INVALIDREGISTER = 32767
 
+// MINREGISTERS is the smallest k that can allocate a block that needs to spill: two PRs to hold the uses of an operation plus the reserved spill register.
+// Blocks with operations that read three registers, like storeAO, need one more.
+const MINREGISTERS = 3
+
 type allocator struct {
 	SRToVR []int
 	LU     []float64
 
 	VRToSpillLoc []int       // a map from a virtual register to its spill location in memory
-	VRToConstant map[int]int // a map from a virtual register to its rematerialization location
+	VRToConstant map[int]int // a map from a virtual register defined by a loadI to its constant, restored with a loadI
 
 	VRToPR      []int
 	PRToVR      []int
@@ -25,16 +36,21 @@ type allocator struct {
 
 	deletePreviousNode bool
 
-	curOperationNode *list.Element
+	edit *ir.Editor // rewrites the block, inserting spill code before the operation being allocated
 
 	memAddress int
 	maxPR      int
 	maxVR      int
 
-	IR *list.List
+	IR *ir.Block
 }
 
-func New(SRToVR []int, LU []float64, IR *list.List, maxVR int, maxPR int, VRToConstant map[int]int) *allocator {
+func New(SRToVR []int, LU []float64, IR *ir.Block, maxVR int, maxPR int, VRToConstant map[int]int) (*allocator, error) {
+	// the allocator is local: every value it keeps in a register lives inside the one block
+	if blocks := len(cfg.New(IR).Blocks); blocks > 1 {
+		return nil, fmt.Errorf("%w: the program has %d blocks, and the allocator only handles one", ErrMultipleBlocks, blocks)
+	}
+
 	VRToPR := make([]int, maxVR)
 	VRToSpillLoc := make([]int, maxVR)
 
@@ -44,7 +60,11 @@ func New(SRToVR []int, LU []float64, IR *list.List, maxVR int, maxPR int, VRToCo
 		VRToSpillLoc[vr] = INVALIDREGISTER
 	}
 
+	// reserve the last PR for spill addresses if the block does not fit into maxPR registers
 	if maxPR < getMaxLive(IR, maxVR) {
+		if minimum := max(MINREGISTERS, getMaxUses(IR)+1); maxPR < minimum {
+			return nil, fmt.Errorf("k = %d is too small: at least %d registers are needed when one is reserved for spilling", maxPR, minimum)
+		}
 		maxPR -= 1
 	}
 
@@ -84,32 +104,39 @@ func New(SRToVR []int, LU []float64, IR *list.List, maxVR int, maxPR int, VRToCo
 		IR: IR,
 	}
 
-	return a
+	return a, nil
 }
 
-func (a *allocator) Allocate() *list.List {
+func (a *allocator) Allocate() *ir.Block {
+	a.edit = a.IR.Edit()
+
 	// iterate over the block
-	for node := a.IR.Front(); node != nil; node = node.Next() {
+	for op, ok := a.edit.Next(); ok; op, ok = a.edit.Next() {
 		a.deletePrevNode()
-
-		op := node.Value.(*m.OperationNode)
-		a.curOperationNode = node
 		// TODO: check if this is necessary or not
 		if op.Opcode == "nop" || op.Opcode == "output" {
 			continue
 		}
 
+		// a constant is loaded where it is used instead: its first use restores it with a loadI like any later one, so
+		// the original loadI would only be a second, redundant copy
+		if _, ok := a.VRToConstant[op.OpThree.VR]; ok && op.Opcode == "loadI" {
+			a.deletePreviousNode = true
+			continue
+		}
+
 		// clear the mark in each PR
 		for i := range a.marks {
 			a.marks[i] = false
 		}
 
 		operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+		operands := m.Opcodes[op.Opcode].Operands
 
 		// go through each use, allocating uses
 		for i, u := range operandList {
 			// skip if it's a definition since its not a use
-			if isDefinition(op.Opcode, i) || !u.Active || !isRegister(op.Opcode, i) {
+			if operands[i] != m.USE || !u.Active {
 				continue
 			}
 
@@ -143,7 +170,7 @@ func (a *allocator) Allocate() *list.List {
 		for i, u := range operandList {
 			// skip if it's a definition since its not a use
 			// TODO: may have to add in more checks (only ones with valid registers)
-			if isDefinition(op.Opcode, i) || !u.Active || !isRegister(op.Opcode, i) {
+			if operands[i] != m.USE || !u.Active {
 				continue
 			}
 
@@ -161,7 +188,7 @@ func (a *allocator) Allocate() *list.List {
 		// allocate defs
 		for i, d := range operandList {
 			// skip if it's not a definition
-			if !isDefinition(op.Opcode, i) || !d.Active || !isRegister(op.Opcode, i) {
+			if operands[i] != m.DEF || !d.Active {
 				continue
 			}
 
@@ -174,15 +201,7 @@ func (a *allocator) Allocate() *list.List {
 		// fmt.Println()
 		// fmt.Println()
 	}
+	a.edit.Done()
 
 	return a.IR
 }
-
-func isDefinition(opcode string, i int) bool {
-	if opcode == "store" {
-		return false
-	} else if i == 2 {
-		return true
-	}
-	return false
-}
@@ -1,9 +1,9 @@
 package allocator
 
 import (
-	"container/list"
 	"math"
 
+	"github.com/bivguy/Comp412/ir"
 	m "github.com/bivguy/Comp412/models"
 )
 
@@ -13,14 +13,14 @@ func (a *allocator) getAPR(VR int, NU float64) int {
 	if l > 0 {
 		pr = a.popStack()
 	} else {
-		// op := a.curOperationNode.Value.(*m.OperationNode)
 		// fmt.Println("About to spill for VR", VR)
-		furthestNextUse := -1
+		// compare as floats: a dead value has a next use of +Inf, which does not survive conversion to int
+		furthestNextUse := -1.0
 
 		for i := 0; i < a.maxPR; i++ {
-			if !a.marks[i] && furthestNextUse < int(a.PRNU[i]) {
+			if !a.marks[i] && furthestNextUse < a.PRNU[i] {
 				pr = i
-				furthestNextUse = int(a.PRNU[i])
+				furthestNextUse = a.PRNU[i]
 			}
 		}
 
@@ -46,22 +46,24 @@ func (a *allocator) freeAPR(pr int) {
 	a.freePRStack = append(a.freePRStack, pr)
 }
 
-func getMaxLive(IR *list.List, maxVR int) int {
+func getMaxLive(IR *ir.Block, maxVR int) int {
 	var maxLive int
 
 	live := make([]bool, maxVR)
 	curLive, maxLive := 0, 0
 
-	for node := IR.Back(); node != nil; node = node.Prev() {
-		op := node.Value.(*m.OperationNode)
+	ops := IR.Ops()
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
 		if op.Opcode == "nop" || op.Opcode == "output" {
 			continue
 		}
 		ops := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+		operands := m.Opcodes[op.Opcode].Operands
 
 		// go through uses
 		for i, u := range ops {
-			if !u.Active || !isRegister(op.Opcode, i) || isDefinition(op.Opcode, i) {
+			if !u.Active || operands[i] != m.USE {
 				continue
 			}
 			vr := u.VR
@@ -74,7 +76,7 @@ func getMaxLive(IR *list.List, maxVR int) int {
 
 		// go through definitions
 		for i, d := range ops {
-			if !d.Active || !isRegister(op.Opcode, i) || !isDefinition(op.Opcode, i) {
+			if !d.Active || operands[i] != m.DEF {
 				continue
 			}
 			vr := d.VR
@@ -90,12 +92,22 @@ func getMaxLive(IR *list.List, maxVR int) int {
 	return maxLive
 }
 
-func isRegister(opcode string, i int) bool {
-	if (opcode == "loadI" && i == 0) || (opcode == "output" && i == 2) {
-		return false
+// getMaxUses returns the most registers read by a single operation of the block, which must all be in PRs at once
+func getMaxUses(IR *ir.Block) int {
+	maxUses := 0
+
+	for _, op := range IR.Ops() {
+		uses := 0
+		for _, kind := range m.Opcodes[op.Opcode].Operands {
+			if kind == m.USE {
+				uses++
+			}
+		}
+
+		maxUses = max(maxUses, uses)
 	}
 
-	return true
+	return maxUses
 }
 
 func (a *allocator) popStack() int {
@@ -4,9 +4,13 @@ import (
 	m "github.com/bivguy/Comp412/models"
 )
 
-// evicts a value from a register and stores its value
+// evicts a value from a register and stores its value. A constant from a loadI is not stored, since restore loads
+// it again with a loadI of its own, as it did for its first use.
 func (a *allocator) spill(pr int) {
-	// op := a.curOperationNode.Value.(*m.OperationNode)
+	if _, ok := a.VRToConstant[a.PRToVR[pr]]; ok {
+		return
+	}
+
 	// fmt.Println("About to spill for ", op)
 	loadIInstruction := &m.OperationNode{
 		Opcode: "loadI",
@@ -24,9 +28,7 @@ func (a *allocator) spill(pr int) {
 		},
 	}
 
-	// connect the linked list
-	a.IR.InsertBefore(loadIInstruction, a.curOperationNode)
-	a.IR.InsertBefore(storeInstruction, a.curOperationNode)
+	a.edit.InsertBefore(loadIInstruction, storeInstruction)
 
 	// update necessary fields
 	a.VRToSpillLoc[a.PRToVR[pr]] = a.memAddress
@@ -42,11 +44,10 @@ func (a *allocator) restore(vr int, pr int) {
 			OpThree: m.Operand{Active: true, PR: pr},
 		}
 
-		a.IR.InsertBefore(loadIInstruction, a.curOperationNode)
+		a.edit.InsertBefore(loadIInstruction)
 		return
 	}
 
-	// fmt.Print("about to restore ", a.curOperationNode.Value, ".\n")
 	loadIInstruction := &m.OperationNode{
 		Opcode:  "loadI",
 		OpOne:   m.Operand{Active: true, SR: a.VRToSpillLoc[vr]},
@@ -60,9 +61,7 @@ func (a *allocator) restore(vr int, pr int) {
 		OpThree: m.Operand{Active: true, VR: vr, PR: pr, NU: a.PRNU[pr]},
 	}
 
-	// connect the linked list
-	a.IR.InsertBefore(loadIInstruction, a.curOperationNode)
-	a.IR.InsertBefore(loadInstruction, a.curOperationNode)
+	a.edit.InsertBefore(loadIInstruction, loadInstruction)
 }
 
 func (a *allocator) deletePrevNode() {
@@ -70,6 +69,6 @@ func (a *allocator) deletePrevNode() {
 		return
 	}
 
-	a.IR.Remove(a.curOperationNode.Prev())
+	a.edit.RemovePrevious()
 	a.deletePreviousNode = false
 }
@@ -0,0 +1,106 @@
+package allocator_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bivguy/Comp412/allocator"
+	m "github.com/bivguy/Comp412/models"
+	p "github.com/bivguy/Comp412/parser"
+	"github.com/bivguy/Comp412/renamer"
+	s "github.com/bivguy/Comp412/scanner"
+	"github.com/bivguy/Comp412/simulator"
+)
+
+// TestCase allocates a program to k registers, then checks how many stores the allocated code has and that it prints
+// the same outputs as the original
+type TestCase struct {
+	description     string
+	input           string
+	k               int
+	expectedStores  int
+	expectedOutputs []int32
+}
+
+var testCases = []TestCase{
+	{
+		description: "spilled values that all come from loadIs",
+		input: "loadI 1 => r1\nloadI 2 => r2\nloadI 3 => r3\nloadI 4 => r4\nadd r1, r2 => r5\nadd r5, r3 => r6\n" +
+			"add r6, r4 => r7\nadd r7, r1 => r8\nloadI 1024 => r9\nstore r8 => r9\noutput 1024\n",
+		k: 3,
+		// the constants are loaded again instead of stored, so only the block's own store is left
+		expectedStores:  1,
+		expectedOutputs: []int32{11},
+	},
+	{
+		description: "a constant read again after a spill",
+		input: "loadI 1 => r0\nloadI 2 => r1\nloadI 1024 => r2\nadd r0, r1 => r3\nadd r3, r1 => r4\nadd r4, r3 => r5\n" +
+			"add r5, r0 => r6\nstore r6 => r2\noutput 1024\n",
+		k: 3,
+		// r0 is evicted for the adds and loaded again for its last use, and r2 is only loaded right before the store
+		expectedStores:  1,
+		expectedOutputs: []int32{9},
+	},
+}
+
+func TestAllocate(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := p.New(s.New(strings.NewReader(tc.input)))
+			IR, _, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+
+			renamer := renamer.New(parser.GetLargestRegister(), IR)
+			renamed := renamer.Rename()
+			for _, op := range renamed.Ops() {
+				if op.Opcode != "loadI" {
+					continue
+				}
+				if constant, ok := renamer.VRToConstant[op.OpThree.VR]; !ok || constant != op.OpOne.SR {
+					t.Errorf("expected vr%d to hold the constant %d", op.OpThree.VR, op.OpOne.SR)
+				}
+			}
+
+			allocator, err := allocator.New(renamer.SRToVR, renamer.LU, renamed.Copy(), renamer.MaxVR, tc.k, renamer.VRToConstant)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			allocated := allocator.Allocate()
+
+			// every loadI the allocator leaves is read before its register is written again
+			stores, loaded := 0, make(map[int]*m.OperationNode)
+			for _, op := range allocated.Ops() {
+				if op.Opcode == "store" {
+					stores++
+				}
+				for i, o := range []m.Operand{op.OpOne, op.OpTwo, op.OpThree} {
+					if !o.Active || !m.IsRegister(op.Opcode, i) {
+						continue
+					}
+					if !m.IsDefinition(op.Opcode, i) {
+						delete(loaded, o.PR)
+					} else if loadI, ok := loaded[o.PR]; ok {
+						t.Errorf("expected %v to be read before r%d is written again by %v", loadI, o.PR, op)
+					}
+				}
+				if op.Opcode == "loadI" {
+					loaded[op.OpThree.PR] = op
+				}
+			}
+			if stores != tc.expectedStores {
+				t.Errorf("expected %d stores but got %d", tc.expectedStores, stores)
+			}
+
+			sim := simulator.New(simulator.PHYSICAL, nil)
+			if err := sim.Run(allocated); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expectedOutputs, sim.Outputs()) {
+				t.Errorf("expected outputs %v but got %v", tc.expectedOutputs, sim.Outputs())
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cfg
+
+import (
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Block is a basic block: a run of operations that is only entered at its first operation and only left after its last
+type Block struct {
+	ID    int    // the block's position in the program, starting at 0 for the entry block
+	Label string // the label on the block's first operation, if any
+	Ops   []*m.OperationNode
+
+	Preds []*Block
+	Succs []*Block
+}
+
+type graph struct {
+	Blocks []*Block // in program order
+	labels map[string]*Block
+}
+
+// New splits the operations into basic blocks and connects each block to the blocks control can reach from it. A new
+// block starts at every labeled operation and after every branch.
+func New(IR *ir.Block) *graph {
+	g := &graph{labels: make(map[string]*Block)}
+
+	var current *Block
+	for _, op := range IR.Ops() {
+		if current == nil || op.Label != "" || m.Opcodes[current.Ops[len(current.Ops)-1].Opcode].Branch {
+			current = &Block{ID: len(g.Blocks), Label: op.Label}
+			g.Blocks = append(g.Blocks, current)
+			if op.Label != "" {
+				g.labels[op.Label] = current
+			}
+		}
+
+		current.Ops = append(current.Ops, op)
+	}
+
+	for _, block := range g.Blocks {
+		for _, succ := range g.successors(block) {
+			connect(block, succ)
+		}
+	}
+
+	return g
+}
+
+// Block returns the block that starts with the label, or nil if there is none
+func (g *graph) Block(label string) *Block {
+	return g.labels[label]
+}
+
+// successors lists the blocks that control can reach directly from the end of the block. The target of a jump is
+// only known at run time, so it may reach any labeled block.
+func (g *graph) successors(block *Block) []*Block {
+	last := block.Ops[len(block.Ops)-1]
+
+	switch last.Opcode {
+	case "jumpI", "cbr":
+		var succs []*Block
+		for _, target := range last.Targets {
+			if succ := g.labels[target]; succ != nil {
+				succs = append(succs, succ)
+			}
+		}
+		return succs
+	case "jump":
+		var succs []*Block
+		for _, succ := range g.Blocks {
+			if succ.Label != "" {
+				succs = append(succs, succ)
+			}
+		}
+		return succs
+	}
+
+	// every other operation falls through to the next block
+	if block.ID+1 < len(g.Blocks) {
+		return []*Block{g.Blocks[block.ID+1]}
+	}
+
+	return nil
+}
+
+// connect adds an edge from one block to another, once even if a branch names the same target twice
+func connect(from *Block, to *Block) {
+	for _, succ := range from.Succs {
+		if succ == to {
+			return
+		}
+	}
+
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// IR returns the block's operations as an ir.Block, the form the renamer, allocator and scheduler work on. The
+// operations are shared, not copied, and keep their IDs.
+func (b *Block) IR() *ir.Block {
+	return ir.New(b.Ops...)
+}
+
+// Branch returns the branch that ends the block, or nil if the block falls through to the next one
+func (b *Block) Branch() *m.OperationNode {
+	last := b.Ops[len(b.Ops)-1]
+	if m.Opcodes[last.Opcode].Branch {
+		return last
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package cfg
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	p "github.com/bivguy/Comp412/parser"
+	s "github.com/bivguy/Comp412/scanner"
+)
+
+// TestCase lists, for each block in order, its label, its number of operations and the IDs of its successors and predecessors
+type TestCase struct {
+	description    string
+	input          string
+	expectedLabels []string
+	expectedSizes  []int
+	expectedSuccs  [][]int
+	expectedPreds  [][]int
+}
+
+var testCases = []TestCase{
+	{
+		description:    "straight-line block",
+		input:          "loadI 4 => r1\nloadI 8 => r2\nadd r1, r2 => r3\n",
+		expectedLabels: []string{""},
+		expectedSizes:  []int{3},
+		expectedSuccs:  [][]int{nil},
+		expectedPreds:  [][]int{nil},
+	},
+	{
+		description:    "labels and branches",
+		input:          "../test_files/branches.i",
+		expectedLabels: []string{"", "L1", "L2", "L3", "L4", "L5", "L6"},
+		expectedSizes:  []int{4, 2, 3, 6, 2, 1, 2},
+		expectedSuccs:  [][]int{{1}, {2, 3}, {1}, {4, 5}, {6}, {6}, nil},
+		expectedPreds:  [][]int{nil, {0, 2}, {1}, {1}, {3}, {3}, {4, 5}},
+	},
+	{
+		description:    "label on its own line",
+		input:          "loadI 1 => r1\nL1:\ncbr r1 -> L1, L1\n",
+		expectedLabels: []string{"", "L1"},
+		expectedSizes:  []int{1, 1},
+		expectedSuccs:  [][]int{{1}, {1}},
+		expectedPreds:  [][]int{nil, {0, 1}},
+	},
+	{
+		description:    "jump to a register",
+		input:          "L1: loadI 1 => r1\njump -> r1\nL2: nop\n",
+		expectedLabels: []string{"L1", "L2"},
+		expectedSizes:  []int{2, 1},
+		expectedSuccs:  [][]int{{0, 1}, nil},
+		expectedPreds:  [][]int{{0}, {0}},
+	},
+}
+
+func TestBlocks(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			input, err := open(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer input.Close()
+
+			parser := p.New(s.New(input))
+			IR, _, err := parser.Parse()
+			if err != nil || parser.ErrorFound {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+
+			var labels []string
+			var sizes []int
+			var succs, preds [][]int
+			for _, block := range New(IR).Blocks {
+				labels = append(labels, block.Label)
+				sizes = append(sizes, len(block.Ops))
+				succs = append(succs, ids(block.Succs))
+				preds = append(preds, ids(block.Preds))
+			}
+
+			if !reflect.DeepEqual(tc.expectedLabels, labels) || !reflect.DeepEqual(tc.expectedSizes, sizes) {
+				t.Errorf("expected blocks %v of sizes %v but got %v of sizes %v", tc.expectedLabels, tc.expectedSizes, labels, sizes)
+			}
+			if !reflect.DeepEqual(tc.expectedSuccs, succs) {
+				t.Errorf("expected successors %v but got %v", tc.expectedSuccs, succs)
+			}
+			if !reflect.DeepEqual(tc.expectedPreds, preds) {
+				t.Errorf("expected predecessors %v but got %v", tc.expectedPreds, preds)
+			}
+		})
+	}
+}
+
+// open reads the input from a file when it names an ILOC file, and otherwise reads the input itself
+func open(input string) (io.ReadCloser, error) {
+	if strings.HasSuffix(input, ".i") {
+		return os.Open(input)
+	}
+
+	return io.NopCloser(strings.NewReader(input)), nil
+}
+
+func ids(blocks []*Block) []int {
+	var ids []int
+	for _, block := range blocks {
+		ids = append(ids, block.ID)
+	}
+
+	return ids
+}
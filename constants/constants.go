@@ -3,20 +3,32 @@ package constants
 import "github.com/bivguy/Comp412/models"
 
 const (
-	MEMOP    models.SyntacticCategory = iota // 0
-	LOADI                                    // 1
-	ARITHOP                                  // 2
-	OUTPUT                                   // 3
-	NOP                                      // 4
-	CONSTANT                                 // 5
-	REGISTER                                 // 6
-	COMMA                                    // 7
-	INTO                                     // 8
-	EOF                                      // 9
-	EOL                                      // 10
-	COMMENT                                  // 11; not used explicity in this project, but rather for discarding
-	INVALID                                  // 12; not used explicitly in this project, but rather for error handling
-	EO                                       // 13; used to signifiy either EOL or EOF
+	MEMOP     models.SyntacticCategory = iota // 0
+	LOADI                                     // 1
+	ARITHOP                                   // 2
+	OUTPUT                                    // 3
+	NOP                                       // 4
+	CONSTANT                                  // 5
+	REGISTER                                  // 6
+	COMMA                                     // 7
+	INTO                                      // 8
+	EOF                                       // 9
+	EOL                                       // 10
+	COMMENT                                   // 11; not used explicity in this project, but rather for discarding
+	INVALID                                   // 12; not used explicitly in this project, but rather for error handling
+	EO                                        // 13; used to signifiy either EOL or EOF
+	LBRACKET                                  // 14; opens a bundle of operations in a schedule
+	SEMICOLON                                 // 15; separates the operations of a bundle
+	RBRACKET                                  // 16; closes a bundle of operations in a schedule
+	IMMOP                                     // 17; an operation with a register and a constant, e.g. addI r1, 4 => r2
+	STOREAI                                   // 18; storeAI r1 => r2, c3
+	STOREAO                                   // 19; storeAO r1 => r2, r3
+	LABEL                                     // 20; a label such as L1, either defined with a ':' or the target of a branch
+	COLON                                     // 21; ends the label at the start of an operation
+	ARROW                                     // 22; separates a branch from its targets
+	JUMPI                                     // 23; jumpI -> L1
+	JUMP                                      // 24; jump -> r1
+	CBR                                       // 25; cbr r1 -> L1, L2
 )
 
 var SyntacticCategories = []string{
@@ -33,4 +45,17 @@ var SyntacticCategories = []string{
 	"EOL",
 	"COMMENT",
 	"INVALID",
+	"EO",
+	"LBRACKET",
+	"SEMICOLON",
+	"RBRACKET",
+	"IMMOP",
+	"STOREAI",
+	"STOREAO",
+	"LABEL",
+	"COLON",
+	"ARROW",
+	"JUMPI",
+	"JUMP",
+	"CBR",
 }
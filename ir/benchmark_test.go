@@ -0,0 +1,123 @@
+package ir_test
+
+import (
+	"container/list"
+	"os"
+	"testing"
+
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+	"github.com/bivguy/Comp412/parser"
+	"github.com/bivguy/Comp412/scanner"
+)
+
+// The benchmarks compare ir.Block with the container/list the passes used to work on, over the 128k operations of
+// t128k.i.txt
+
+func t128k(b *testing.B) *ir.Block {
+	file, err := os.Open("../test_files/t128k.i.txt")
+	if err != nil {
+		b.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	IR, _, err := parser.New(scanner.New(file)).Parse()
+	if err != nil {
+		b.Fatalf("Unexpected error: %v", err)
+	}
+
+	return IR
+}
+
+func toList(IR *ir.Block) *list.List {
+	l := list.New()
+	for _, op := range IR.Ops() {
+		l.PushBack(op)
+	}
+
+	return l
+}
+
+// BenchmarkIterate walks every operation from the last to the first, the way the renamer does
+func BenchmarkIterate(b *testing.B) {
+	IR := t128k(b)
+	l := toList(IR)
+
+	b.Run("list", func(b *testing.B) {
+		for range b.N {
+			registers := 0
+			for node := l.Back(); node != nil; node = node.Prev() {
+				registers += node.Value.(*m.OperationNode).OpThree.SR
+			}
+		}
+	})
+	b.Run("block", func(b *testing.B) {
+		for range b.N {
+			registers := 0
+			ops := IR.Ops()
+			for i := len(ops) - 1; i >= 0; i-- {
+				registers += ops[i].OpThree.SR
+			}
+		}
+	})
+}
+
+// BenchmarkCopy copies every operation, as the pipeline does before each pass that changes the IR
+func BenchmarkCopy(b *testing.B) {
+	IR := t128k(b)
+	l := toList(IR)
+
+	b.Run("list", func(b *testing.B) {
+		for range b.N {
+			copied := list.New()
+			for node := l.Front(); node != nil; node = node.Next() {
+				op := *node.Value.(*m.OperationNode)
+				copied.PushBack(&op)
+			}
+		}
+	})
+	b.Run("block", func(b *testing.B) {
+		for range b.N {
+			IR.Copy()
+		}
+	})
+}
+
+// BenchmarkSpill inserts a loadI and a store before every fourth operation, as the allocator does when it spills
+func BenchmarkSpill(b *testing.B) {
+	IR := t128k(b)
+
+	b.Run("list", func(b *testing.B) {
+		for range b.N {
+			b.StopTimer()
+			l := toList(IR)
+			b.StartTimer()
+
+			i := 0
+			for node := l.Front(); node != nil; node = node.Next() {
+				if i%4 == 0 {
+					l.InsertBefore(&m.OperationNode{Opcode: "loadI"}, node)
+					l.InsertBefore(&m.OperationNode{Opcode: "store"}, node)
+				}
+				i++
+			}
+		}
+	})
+	b.Run("block", func(b *testing.B) {
+		for range b.N {
+			b.StopTimer()
+			block := ir.New(IR.Ops()...)
+			b.StartTimer()
+
+			e := block.Edit()
+			i := 0
+			for _, ok := e.Next(); ok; _, ok = e.Next() {
+				if i%4 == 0 {
+					e.InsertBefore(&m.OperationNode{Opcode: "loadI"}, &m.OperationNode{Opcode: "store"})
+				}
+				i++
+			}
+			e.Done()
+		}
+	})
+}
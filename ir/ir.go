@@ -0,0 +1,155 @@
+package ir
+
+import (
+	"slices"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Block is a sequence of operations kept in one slice, in program order. Every operation in a block has an ID, starting
+// at 1, that stays the same as operations are inserted and removed around it.
+type Block struct {
+	ops    []*m.OperationNode
+	nextID int
+}
+
+// New returns a block of the given operations. Operations that do not have an ID yet are numbered in order after the
+// largest ID among the others, so a block made from the operations of another keeps their IDs.
+func New(ops ...*m.OperationNode) *Block {
+	b := &Block{ops: make([]*m.OperationNode, 0, len(ops)), nextID: 1}
+	for _, op := range ops {
+		b.nextID = max(b.nextID, op.ID+1)
+	}
+	b.Append(ops...)
+
+	return b
+}
+
+// Len returns the number of operations in the block
+func (b *Block) Len() int {
+	return len(b.ops)
+}
+
+// At returns the operation at index i
+func (b *Block) At(i int) *m.OperationNode {
+	return b.ops[i]
+}
+
+// Ops returns the operations of the block in order. The slice belongs to the block: it can be ranged over and its
+// operations changed, but it must not be appended to, and it is only valid until the block is next changed.
+func (b *Block) Ops() []*m.OperationNode {
+	return b.ops
+}
+
+// Append adds operations to the end of the block
+func (b *Block) Append(ops ...*m.OperationNode) {
+	for _, op := range ops {
+		b.number(op)
+	}
+	b.ops = append(b.ops, ops...)
+}
+
+// InsertBefore inserts operations before the one at index i, moving every operation after them. To insert before many
+// operations, use an Editor instead, which costs one pass over the block.
+func (b *Block) InsertBefore(i int, ops ...*m.OperationNode) {
+	for _, op := range ops {
+		b.number(op)
+	}
+	b.ops = slices.Insert(b.ops, i, ops...)
+}
+
+// Remove removes the operation at index i
+func (b *Block) Remove(i int) {
+	b.ops = slices.Delete(b.ops, i, i+1)
+}
+
+// Index returns the index of the operation with the given ID, or -1 if it is not in the block
+func (b *Block) Index(id int) int {
+	for i, op := range b.ops {
+		if op.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Copy returns a block with a copy of each operation, keeping their IDs, so a pass can change the copy and leave this
+// block as it was
+func (b *Block) Copy() *Block {
+	copied := &Block{ops: make([]*m.OperationNode, len(b.ops)), nextID: b.nextID}
+
+	nodes := make([]m.OperationNode, len(b.ops))
+	for i, op := range b.ops {
+		nodes[i] = *op
+		copied.ops[i] = &nodes[i]
+	}
+
+	return copied
+}
+
+// number gives an operation without an ID the next one
+func (b *Block) number(op *m.OperationNode) {
+	if op.ID == 0 {
+		op.ID = b.nextID
+		b.nextID++
+	}
+}
+
+// Edit returns an Editor that rewrites the block from its first operation to its last
+func (b *Block) Edit() *Editor {
+	return &Editor{block: b, out: make([]*m.OperationNode, 0, len(b.ops))}
+}
+
+// Editor rewrites a block in one pass from front to back. Operations inserted before the current one and the removal
+// of the one kept just before it only touch the end of the rewritten block, so adding spill code before many
+// operations costs no more than appending it.
+type Editor struct {
+	block   *Block
+	out     []*m.OperationNode
+	next    int
+	current *m.OperationNode
+}
+
+// Next keeps the current operation and moves on to the next one, returning false once every operation has been seen
+func (e *Editor) Next() (*m.OperationNode, bool) {
+	if e.current != nil {
+		e.out = append(e.out, e.current)
+		e.current = nil
+	}
+
+	if e.next >= len(e.block.ops) {
+		return nil, false
+	}
+
+	e.current = e.block.ops[e.next]
+	e.next++
+	return e.current, true
+}
+
+// InsertBefore inserts operations before the current one
+func (e *Editor) InsertBefore(ops ...*m.OperationNode) {
+	for _, op := range ops {
+		e.block.number(op)
+	}
+	e.out = append(e.out, ops...)
+}
+
+// RemovePrevious removes the operation just before the current one
+func (e *Editor) RemovePrevious() {
+	if len(e.out) > 0 {
+		e.out = e.out[:len(e.out)-1]
+	}
+}
+
+// Done keeps the current operation and any that were not reached, and replaces the block's operations with the
+// rewritten ones
+func (e *Editor) Done() {
+	if e.current != nil {
+		e.out = append(e.out, e.current)
+		e.current = nil
+	}
+
+	e.block.ops = append(e.out, e.block.ops[e.next:]...)
+	e.next = len(e.block.ops)
+}
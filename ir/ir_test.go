@@ -0,0 +1,123 @@
+package ir
+
+import (
+	"reflect"
+	"testing"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+type TestCase struct {
+	description string
+	edit        func(b *Block)
+	expectedOps []string // the opcodes of the block after the edit
+	expectedIDs []int
+}
+
+// block returns a block of operations with the given opcodes, so the tests can tell them apart
+func block(opcodes ...string) *Block {
+	b := New()
+	for _, opcode := range opcodes {
+		b.Append(&m.OperationNode{Opcode: opcode})
+	}
+
+	return b
+}
+
+var blockTestCases = []TestCase{
+	{
+		description: "append",
+		edit:        func(b *Block) {},
+		expectedOps: []string{"op1", "op2", "op3"},
+		expectedIDs: []int{1, 2, 3},
+	},
+	{
+		description: "insert before",
+		edit: func(b *Block) {
+			b.InsertBefore(1, &m.OperationNode{Opcode: "new"})
+		},
+		expectedOps: []string{"op1", "new", "op2", "op3"},
+		expectedIDs: []int{1, 4, 2, 3},
+	},
+	{
+		description: "remove",
+		edit: func(b *Block) {
+			b.Remove(0)
+			b.Append(&m.OperationNode{Opcode: "new"})
+		},
+		expectedOps: []string{"op2", "op3", "new"},
+		expectedIDs: []int{2, 3, 4},
+	},
+	{
+		description: "editor inserts spill code and removes the previous operation",
+		edit: func(b *Block) {
+			e := b.Edit()
+			for op, ok := e.Next(); ok; op, ok = e.Next() {
+				switch op.Opcode {
+				case "op2":
+					e.InsertBefore(&m.OperationNode{Opcode: "loadI"}, &m.OperationNode{Opcode: "store"})
+				case "op3":
+					e.RemovePrevious()
+				}
+			}
+			e.Done()
+		},
+		expectedOps: []string{"op1", "loadI", "store", "op3"},
+		expectedIDs: []int{1, 4, 5, 3},
+	},
+	{
+		description: "editor done early keeps the rest",
+		edit: func(b *Block) {
+			e := b.Edit()
+			e.Next()
+			e.InsertBefore(&m.OperationNode{Opcode: "new"})
+			e.Done()
+		},
+		expectedOps: []string{"new", "op1", "op2", "op3"},
+		expectedIDs: []int{4, 1, 2, 3},
+	},
+}
+
+func TestBlock(t *testing.T) {
+	for _, tc := range blockTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			b := block("op1", "op2", "op3")
+			tc.edit(b)
+
+			var ops []string
+			var ids []int
+			for _, op := range b.Ops() {
+				ops = append(ops, op.Opcode)
+				ids = append(ids, op.ID)
+			}
+
+			if !reflect.DeepEqual(tc.expectedOps, ops) || !reflect.DeepEqual(tc.expectedIDs, ids) {
+				t.Errorf("expected %v with IDs %v but got %v with IDs %v", tc.expectedOps, tc.expectedIDs, ops, ids)
+			}
+			for i, id := range ids {
+				if b.Index(id) != i {
+					t.Errorf("expected the operation with ID %d at %d but got %d", id, i, b.Index(id))
+				}
+			}
+		})
+	}
+}
+
+func TestCopy(t *testing.T) {
+	b := block("op1", "op2")
+	copied := b.Copy()
+	copied.At(0).Opcode = "changed"
+	copied.Append(&m.OperationNode{Opcode: "new"})
+
+	if b.At(0).Opcode != "op1" || b.Len() != 2 {
+		t.Errorf("expected the original block to be left as it was")
+	}
+	if copied.At(0).ID != 1 || copied.At(2).ID != 3 {
+		t.Errorf("expected the copy to keep the IDs and go on numbering after them")
+	}
+
+	// a block made from the operations of another keeps their IDs
+	if shared := New(b.At(1)); shared.At(0).ID != 2 {
+		t.Errorf("expected ID 2 but got %d", shared.At(0).ID)
+	}
+}
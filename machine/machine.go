@@ -0,0 +1,167 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Description is a machine description: how many operations issue per cycle, the functional unit behind each issue
+// slot, and the latency and units of each opcode
+type Description struct {
+	Name       string            `json:"name"`
+	IssueWidth int               `json:"issueWidth"`
+	Units      []Unit            `json:"units"` // one unit per issue slot, in slot order
+	Opcodes    map[string]Opcode `json:"opcodes"`
+}
+
+// Unit is the functional unit behind an issue slot
+type Unit struct {
+	Name    string   `json:"name"`
+	Classes []string `json:"classes"` // the classes of operations this unit can execute, e.g. "memory" or "multiply"
+}
+
+// Opcode describes how an operation is executed
+type Opcode struct {
+	Latency  int    `json:"latency"`
+	Class    string `json:"class"`    // the class of unit that can execute it; empty means any unit
+	PerCycle int    `json:"perCycle"` // the most operations with this opcode issued in one cycle; 0 means no limit
+}
+
+// Default describes the COMP 412 Lab 3 machine: two issue slots, loads and stores only in slot one, mult and div only
+// in slot two, and one output per cycle
+func Default() *Description {
+	return &Description{
+		Name:       "comp412",
+		IssueWidth: 2,
+		Units: []Unit{
+			{Name: "f0", Classes: []string{"memory"}},
+			{Name: "f1", Classes: []string{"multiply"}},
+		},
+		Opcodes: map[string]Opcode{
+			"load":    {Latency: 6, Class: "memory"},
+			"loadAI":  {Latency: 6, Class: "memory"},
+			"loadAO":  {Latency: 6, Class: "memory"},
+			"store":   {Latency: 6, Class: "memory"},
+			"storeAI": {Latency: 6, Class: "memory"},
+			"storeAO": {Latency: 6, Class: "memory"},
+			"mult":    {Latency: 3, Class: "multiply"},
+			"multI":   {Latency: 3, Class: "multiply"},
+			"div":     {Latency: 3, Class: "multiply"},
+			"divI":    {Latency: 3, Class: "multiply"},
+			"add":     {Latency: 1},
+			"addI":    {Latency: 1},
+			"sub":     {Latency: 1},
+			"subI":    {Latency: 1},
+			"lshift":  {Latency: 1},
+			"lshiftI": {Latency: 1},
+			"rshift":  {Latency: 1},
+			"rshiftI": {Latency: 1},
+			"cmp_LT":  {Latency: 1},
+			"cmp_LE":  {Latency: 1},
+			"cmp_EQ":  {Latency: 1},
+			"cmp_GE":  {Latency: 1},
+			"cmp_GT":  {Latency: 1},
+			"cmp_NE":  {Latency: 1},
+			"i2i":     {Latency: 1},
+			"loadI":   {Latency: 1},
+			"output":  {Latency: 1, PerCycle: 1},
+			"nop":     {Latency: 1},
+			"jumpI":   {Latency: 1},
+			"jump":    {Latency: 1},
+			"cbr":     {Latency: 1},
+		},
+	}
+}
+
+// Load reads a machine description from a JSON file
+func Load(path string) (*Description, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Description{}
+	err = json.Unmarshal(data, d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid machine description %s: %w", path, err)
+	}
+
+	err = d.validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid machine description %s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+func (d *Description) validate() error {
+	// a machine that issues nothing could never schedule an operation
+	if d.IssueWidth < 1 {
+		return fmt.Errorf("issue width is %d; at least one operation must issue per cycle", d.IssueWidth)
+	}
+
+	if d.IssueWidth != len(d.Units) {
+		return fmt.Errorf("issue width is %d but %d units are described", d.IssueWidth, len(d.Units))
+	}
+
+	// every ILOC opcode must be described, so a misspelled name or a forgotten opcode is caught here instead of
+	// silently taking one cycle on any unit
+	for _, name := range slices.Sorted(maps.Keys(d.Opcodes)) {
+		if _, ok := m.Opcodes[name]; !ok {
+			return fmt.Errorf("opcode %s is not an ILOC opcode", name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Opcodes)) {
+		if _, ok := d.Opcodes[name]; !ok {
+			return fmt.Errorf("opcode %s is not described; every ILOC opcode needs a latency", name)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.Opcodes)) {
+		opcode := d.Opcodes[name]
+		if opcode.Latency < 1 {
+			return fmt.Errorf("opcode %s has latency %d; every latency must be at least 1", name, opcode.Latency)
+		}
+
+		if len(d.UnitsFor(name)) == 0 {
+			return fmt.Errorf("opcode %s needs a unit of class %q but no unit has that class", name, opcode.Class)
+		}
+	}
+
+	return nil
+}
+
+// Latency returns the number of cycles an operation takes before its result can be used. A loaded description has every
+// opcode; one built in code that leaves an opcode out gives it one cycle.
+func (d *Description) Latency(opcode string) int {
+	op, ok := d.Opcodes[opcode]
+	if !ok {
+		return 1
+	}
+
+	return op.Latency
+}
+
+// PerCycle returns the most operations with this opcode that can issue in one cycle, or 0 if there is no limit
+func (d *Description) PerCycle(opcode string) int {
+	return d.Opcodes[opcode].PerCycle
+}
+
+// UnitsFor returns the issue slots whose unit can execute the opcode, in slot order
+func (d *Description) UnitsFor(opcode string) []int {
+	class := d.Opcodes[opcode].Class
+
+	var slots []int
+	for i, unit := range d.Units {
+		if class == "" || slices.Contains(unit.Classes, class) {
+			slots = append(slots, i)
+		}
+	}
+
+	return slots
+}
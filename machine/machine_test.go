@@ -0,0 +1,151 @@
+package machine
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+type TestCase struct {
+	description   string
+	json          string
+	expectedError bool
+}
+
+// opcodes describes every ILOC opcode but the ones given with a latency of one, as JSON object members
+func opcodes(except ...string) string {
+	var members []string
+	for _, name := range slices.Sorted(maps.Keys(m.Opcodes)) {
+		if !slices.Contains(except, name) {
+			members = append(members, fmt.Sprintf(`"%s": {"latency": 1}`, name))
+		}
+	}
+
+	return strings.Join(members, ", ")
+}
+
+var loadTestCases = []TestCase{
+	{
+		description: "valid description",
+		json:        `{"issueWidth": 1, "units": [{"name": "f0"}], "opcodes": {` + opcodes("add") + `, "add": {"latency": 2}}}`,
+	},
+	{
+		description:   "issue width does not match the units",
+		json:          `{"issueWidth": 2, "units": [{"name": "f0"}], "opcodes": {` + opcodes() + `}}`,
+		expectedError: true,
+	},
+	{
+		description:   "latency of zero",
+		json:          `{"issueWidth": 1, "units": [{"name": "f0"}], "opcodes": {` + opcodes("add") + `, "add": {"latency": 0}}}`,
+		expectedError: true,
+	},
+	{
+		description:   "no unit of the opcode's class",
+		json:          `{"issueWidth": 1, "units": [{"name": "f0", "classes": ["memory"]}], "opcodes": {` + opcodes("mult") + `, "mult": {"latency": 3, "class": "multiply"}}}`,
+		expectedError: true,
+	},
+	{
+		description:   "misspelled opcode",
+		json:          `{"issueWidth": 1, "units": [{"name": "f0"}], "opcodes": {` + opcodes() + `, "mul": {"latency": 3}}}`,
+		expectedError: true,
+	},
+	{
+		description:   "missing opcode",
+		json:          `{"issueWidth": 1, "units": [{"name": "f0"}], "opcodes": {` + opcodes("cbr") + `}}`,
+		expectedError: true,
+	},
+	{
+		description:   "issue width of zero",
+		json:          `{"issueWidth": 0, "units": []}`,
+		expectedError: true,
+	},
+	{
+		description:   "no unit of the class of an opcode a program may use",
+		json:          `{"issueWidth": 2, "units": [{"name": "f0", "classes": ["multiply"]}, {"name": "f1", "classes": ["multiply"]}], "opcodes": {` + opcodes("load") + `, "load": {"latency": 6, "class": "memory"}}}`,
+		expectedError: true,
+	},
+	{
+		description:   "malformed json",
+		json:          `{"issueWidth": 1,`,
+		expectedError: true,
+	},
+}
+
+func TestLoad(t *testing.T) {
+	for _, tc := range loadTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "machine.json")
+			if err := os.WriteFile(path, []byte(tc.json), 0o644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+
+			_, err := Load(path)
+			if tc.expectedError && err == nil {
+				t.Errorf("expected an error but got none")
+			}
+			if !tc.expectedError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultMatchesFile(t *testing.T) {
+	d, err := Load("comp412.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(Default(), d) {
+		t.Errorf("comp412.json does not match Default(): %+v", d)
+	}
+}
+
+func TestUnitsFor(t *testing.T) {
+	d := Default()
+
+	expected := map[string][]int{
+		"load":   {0},
+		"store":  {0},
+		"mult":   {1},
+		"add":    {0, 1},
+		"output": {0, 1},
+		"cbr":    {0, 1},
+	}
+
+	for opcode, slots := range expected {
+		if actual := d.UnitsFor(opcode); !reflect.DeepEqual(slots, actual) {
+			t.Errorf("expected %s to use slots %v but got %v", opcode, slots, actual)
+		}
+	}
+}
+
+func TestWideMachine(t *testing.T) {
+	d, err := Load("wide4.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if d.IssueWidth != 4 {
+		t.Errorf("expected an issue width of 4 but got %d", d.IssueWidth)
+	}
+
+	expected := map[string][]int{
+		"load": {0, 1},
+		"mult": {2, 3},
+		"add":  {0, 1, 2, 3},
+	}
+
+	for opcode, slots := range expected {
+		if actual := d.UnitsFor(opcode); !reflect.DeepEqual(slots, actual) {
+			t.Errorf("expected %s to use slots %v but got %v", opcode, slots, actual)
+		}
+	}
+}
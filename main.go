@@ -1,16 +1,23 @@
 package main
 
 import (
-	"container/list"
+	"bufio"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/bivguy/Comp412/constants"
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
 	m "github.com/bivguy/Comp412/models"
 	"github.com/bivguy/Comp412/parser"
-	"github.com/bivguy/Comp412/renamer"
-	"github.com/bivguy/Comp412/scheduler"
+	"github.com/bivguy/Comp412/pipeline"
+	"github.com/bivguy/Comp412/simulator"
 
 	"github.com/bivguy/Comp412/scanner"
 )
@@ -18,13 +25,55 @@ import (
 func main() {
 	hFlag := flag.Bool("h", false, "Display help")
 
+	rFlag := flag.Bool("r", false, "Prints the Intermediate Representation as a table of SR/VR/PR/NU for each operand")
+
+	pFlag := flag.Bool("p", false, "Parses the input block and reports whether it succeeded")
+
+	sFlag := flag.Bool("s", false, "Prints the token stream produced by the scanner")
+
 	xFlag := flag.Bool("x", false, "Displays the Renamed Intermediate Representation Output")
 
+	liveFlag := flag.Bool("live", false, "Prints each VR's live range and the register pressure at each line of the renamed block, with MaxLive")
+
+	kFlag := flag.Int("k", 0, "Allocates the input block to k physical registers")
+
+	simFlag := flag.Bool("sim", false, "Simulates the output of the selected pass instead of printing it")
+
+	cyclesFlag := flag.Bool("cycles", false, "Schedules the input block and simulates the schedule cycle by cycle")
+
+	verifyFlag := flag.Bool("verify", false, "Simulates the output of the selected pass and checks it against the block's //OUTPUT header")
+
+	equivFlag := flag.Bool("equiv", false, "Checks that the output of the selected pass computes the same outputs and memory as the input block")
+
+	bFlag := flag.Bool("b", false, "Reads the input as a schedule, one \"[ op ; op ]\" bundle per line, and runs it with -cycles or -verify")
+
+	mFlag := flag.String("m", "", "Reads the latencies and functional unit rules from a JSON machine description instead of using the COMP 412 machine")
+
+	iFlag := flag.String("i", "", "Initial memory for -sim, in sim's format: \"<address> <value> <value> ...\"; defaults to the block's //SIM INPUT header")
+
+	diagnosticsFlag := flag.String("diagnostics", "text", "Reports scanner and parser errors as \"text\", with the source line, or as \"json\", one object per line")
+
+	maxErrorsFlag := flag.Int("max-errors", 0, "Stops scanning and parsing after this many errors; 0 means no limit")
+
+	parallelFlag := flag.Int("parallel", 0, "Parses the input in about this many chunks of whole lines on separate goroutines; 0 or 1 parses it in one piece")
+
+	validateFlag := flag.Bool("validate", false, "Warns about registers read before they are written, loadI and output constants that do not fit in 32 bits and very large register numbers")
+
+	strictFlag := flag.Bool("strict", false, "Validates the input block like -validate, but reports the problems as errors and stops")
+
+	commentsFlag := flag.Bool("comments", false, "Echoes the comments of the input block above the operations they were written with in -r, -x, -k and scheduled output")
+
 	flag.Parse()
 
+	if *diagnosticsFlag != "text" && *diagnosticsFlag != "json" {
+		fmt.Fprintf(os.Stderr, "ERROR: -diagnostics must be text or json, not %q\n", *diagnosticsFlag)
+		os.Exit(1)
+	}
+	diagnostics := diagnosticPrinter{json: *diagnosticsFlag == "json"}
+
 	// politely report that only a single flag should be passed in
-	if flag.NFlag() > 1 {
-		fmt.Fprintln(os.Stderr, "Only one flag should be passed at a time; using highest priority (-h, -r, -p, -s).\n")
+	if countModes(*hFlag, *rFlag, *pFlag, *sFlag, *xFlag, *liveFlag, *kFlag != 0) > 1 {
+		fmt.Fprintln(os.Stderr, "Only one flag should be passed at a time; using highest priority (-h, -r, -p, -s, -x, -live, -k).")
 	}
 
 	if *hFlag {
@@ -46,38 +95,180 @@ func main() {
 		helpMessage()
 		return
 	}
-	// open the file
-	path := args[0]
-	file, err := os.Open(path)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: Failed to open file: %v\n", err)
-		helpMessage()
+	// open the file, or read the block from stdin when the filename is '-'
+	var file io.Reader = os.Stdin
+	if path := args[0]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Failed to open file: %v\n", err)
+			helpMessage()
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	var err error
+	description := machine.Default()
+	if *mFlag != "" {
+		description, err = machine.Load(*mFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *sFlag && !*rFlag && !*pFlag {
+		if scan(scanner.New(file), diagnostics, *maxErrorsFlag) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *bFlag {
+		parser := parser.New(scanner.New(file))
+		parser.MaxErrors = *maxErrorsFlag
+		schedule, found, err := parser.ParseSchedule()
+		diagnostics.print(found)
+		if err != nil {
+			fmt.Println("Parse found errors")
+			os.Exit(1)
+		}
+
+		run := simulation{input: *iFlag, metadata: parser.GetMetadata(), verify: *verifyFlag, machine: description}
+		if *cyclesFlag || *verifyFlag {
+			run.simulateSchedule(schedule, simulator.SOURCE)
+			return
+		}
+		fmt.Printf("Parse succeeded. Processed %d bundles.\n", len(schedule))
 		return
 	}
-	defer file.Close()
 
-	// scan and parse
-	scanner := scanner.New(file)
-	parser := parser.New(scanner)
-	IR, err := parser.Parse()
-	if parser.ErrorFound || err != nil {
+	if *pFlag && !*rFlag && !*validateFlag && !*strictFlag {
+		count, ok := check(file, *parallelFlag, *maxErrorsFlag, diagnostics)
+		if !ok {
+			fmt.Println("Parse found errors")
+			os.Exit(1)
+		}
+		fmt.Printf("Parse succeeded. Processed %d operations.\n", count)
+		return
+	}
+
+	// parse, then run the passes selected by the flags
+	program := pipeline.NewProgram(description)
+	err = pipeline.ParseParallel(file, *parallelFlag, *maxErrorsFlag).Run(program)
+	diagnostics.print(program.Diagnostics)
+	if err != nil {
 		fmt.Println("Parse found errors")
+		os.Exit(1)
+	}
+	IR := program.Parsed.IR
+
+	if *validateFlag || *strictFlag {
+		found := len(program.Diagnostics)
+		err = pipeline.Validate(*strictFlag).Run(program)
+		diagnostics.print(program.Diagnostics[found:])
+		if err != nil {
+			fmt.Println("Validation found errors")
+			os.Exit(1)
+		}
+	}
+
+	if *pFlag && !*rFlag {
+		fmt.Printf("Parse succeeded. Processed %d operations.\n", IR.Len())
 		return
 	}
 
-	// rename
-	largestRegister := parser.GetLargestRegister()
-	renamer := renamer.New(largestRegister, IR)
-	renamedIR := renamer.Rename()
+	// the passes leave the parsed block as written, so it can be run again for -equiv
+	run := simulation{input: *iFlag, metadata: program.Parsed.Metadata, verify: *verifyFlag, original: IR, machine: description}
+
+	// simulate the block as written
+	if (*simFlag || *verifyFlag) && !*xFlag && *kFlag == 0 && !*cyclesFlag {
+		run.simulate(IR, simulator.SOURCE)
+		return
+	}
+
+	// the live ranges are only tracked when they are printed
+	rename := pipeline.Rename()
+	if *liveFlag {
+		rename = pipeline.RenameWithLiveRanges()
+	}
+
+	passes := pipeline.NewManager(rename)
+	switch {
+	case *rFlag || *xFlag || *liveFlag:
+	case *kFlag != 0:
+		passes.Add(pipeline.Allocate(*kFlag))
+	default:
+		passes.Add(pipeline.Schedule())
+	}
+
+	if err := passes.Run(program); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *rFlag {
+		fmt.Print(irTable(program.Renamed.IR, *commentsFlag))
+		return
+	}
 
 	if *xFlag {
-		fmt.Println(renameIR(renamedIR))
+		renamedIR := program.Renamed.IR
+		if *equivFlag {
+			run.equivalent(renamedIR, simulator.VIRTUAL)
+			return
+		}
+		if *simFlag || *verifyFlag {
+			run.simulate(renamedIR, simulator.VIRTUAL)
+			return
+		}
+		fmt.Println(renameIR(renamedIR, *commentsFlag))
+		return
+	}
+
+	if *liveFlag {
+		fmt.Print(liveReport(program.Renamed))
 		return
 	}
 
-	// if neither the xFlag or the hFlag are provided, schedule on the input file passed in
-	scheduler := scheduler.NewSchedule(renamedIR)
-	scheduler.PrintSchedule()
+	if *kFlag != 0 {
+		allocatedIR := program.Allocated.IR
+		if *equivFlag {
+			run.equivalent(allocatedIR, simulator.PHYSICAL)
+			return
+		}
+		if *simFlag || *verifyFlag {
+			run.simulate(allocatedIR, simulator.PHYSICAL)
+			return
+		}
+		fmt.Println(allocateIR(allocatedIR, *commentsFlag))
+		return
+	}
+
+	// if none of the xFlag, kFlag or hFlag are provided, print the schedule of the input file passed in
+	bundles := program.Scheduled.Bundles
+	if *equivFlag {
+		run.equivalentSchedule(bundles)
+		return
+	}
+	if *cyclesFlag {
+		run.simulateSchedule(bundles, simulator.VIRTUAL)
+		return
+	}
+	fmt.Println(scheduleIR(bundles, *commentsFlag))
+}
+
+// countModes counts how many of the mutually exclusive mode flags were passed in
+func countModes(modes ...bool) int {
+	count := 0
+	for _, mode := range modes {
+		if mode {
+			count++
+		}
+	}
+
+	return count
 }
 
 // helpMessage prints to the command line all the possible commands for the 412fe applications
@@ -89,54 +280,418 @@ func helpMessage() {
 	fmt.Println("Flags:")
 
 	fmt.Println("  -h \t\t Display this help message.")
+	fmt.Println("  -r <filename>\t Prints the Intermediate Representation as a table with the SR, VR, PR and NU of each operand.")
+	fmt.Println("  -p <filename>\t Parses the input block and reports success along with the number of operations, or the errors found.")
+	fmt.Println("  -s <filename>\t Prints the token stream produced by the scanner, one token per line.")
 	fmt.Println("  -x <filename>\t scans and parse the input block. It should then perform renaming the code in the input block and print the results to the standard output stream.")
+	fmt.Println("  -live <filename>  Renames the input block and prints each VR's definition line, last-use line and live range")
+	fmt.Println("                    length, then the number of values live at each line and MaxLive, the fewest registers")
+	fmt.Println("                    -k needs to allocate the block without spilling.")
+	fmt.Println("  -k <k> <filename>  scans, parses and renames the input block, then allocates it to k physical registers and prints the allocated code.")
 
+	fmt.Println("  -sim [-i \"<address> <values>\"] <filename>")
+	fmt.Println("                    Runs the input block (or the output of -x or -k) on the built-in ILOC simulator and prints each output value.")
+	fmt.Println("                    -i initializes memory starting at <address>, the same way sim's -i flag does.")
+	fmt.Println("  -verify <filename>")
+	fmt.Println("                    Simulates the input block (or the output of -x, -k or -cycles) with the memory in its //SIM INPUT")
+	fmt.Println("                    header and checks the output values against its //OUTPUT header.")
+	fmt.Println("  -equiv [-x | -k <k>] <filename>")
+	fmt.Println("                    Runs the input block and the renamed (-x), allocated (-k) or scheduled (no flag) code, then")
+	fmt.Println("                    compares every output value and the final memory, ignoring the allocator's spill area.")
+	fmt.Println("  -cycles [-i \"<address> <values>\"] <filename>")
+	fmt.Println("                    Schedules the input block and runs the schedule cycle by cycle, reporting the cycle count and")
+	fmt.Println("                    any operation that reads a register or memory word before its producer has finished.")
+	fmt.Println("  -b [-cycles | -verify] <filename>")
+	fmt.Println("                    Reads the input as a schedule, one \"[ op ; op ]\" bundle per line, as printed by the scheduler.")
+	fmt.Println("                    With -cycles or -verify, runs the bundles cycle by cycle as described above.")
+	fmt.Println("  -parallel <N>     Parses the input in about N chunks of whole lines on separate goroutines, which is faster")
+	fmt.Println("                    for very large blocks; the IR and errors are the same as parsing it in one piece.")
+	fmt.Println("  -validate         Warns about registers read before any operation writes them, loadI and output constants")
+	fmt.Println("                    that do not fit in 32 bits and register numbers above r65536, before running the selected pass.")
+	fmt.Println("  -strict           Validates like -validate, but reports the problems as errors and stops (exit code 1).")
+	fmt.Println("  -comments         Echoes the input's comments above the operations they were written with, in the output of")
+	fmt.Println("                    -r, -x, -k and the scheduler.")
+	fmt.Println("  -m <machine.json>  Reads the latencies and functional unit rules used by the scheduler and -cycles from a")
+	fmt.Println("                    JSON machine description (see machine/comp412.json) instead of the COMP 412 machine.")
 	fmt.Println("  <filename>        (No flag.) Schedule the input ILOC code using the Lab 3 scheduler.")
 	fmt.Println("                    The resulting scheduled code is printed to standard output.")
 	fmt.Println()
+	fmt.Println("<filename> may be '-' to read the input block from standard input.")
 
-	fmt.Println("No flag provided: behaves as if -p <filename> was specified.")
+	fmt.Println("Mode flags are mutually exclusive; priority: -h > -r > -p > -s > -x > -live > -k.")
 }
 
-func renameIR(ir *list.List) string {
+func renameIR(IR *ir.Block, comments bool) string {
 	var b strings.Builder
 
-	for e := ir.Front(); e != nil; e = e.Next() {
-		var op *m.OperationNode
-		switch v := e.Value.(type) {
-		case *m.OperationNode:
-			op = v
-		case m.OperationNode:
-			tmp := v
-			op = &tmp
-		default:
+	for _, op := range IR.Ops() {
+		if comments {
+			writeComments(&b, op)
+		}
+		fmt.Fprintf(&b, op.String()+"\n")
+	}
+
+	return b.String()
+}
+
+// simulation holds what is needed to run the output of a pass on the built-in simulator
+type simulation struct {
+	input    string
+	metadata m.Metadata
+	verify   bool
+
+	original *ir.Block // the input block as written, for -equiv
+	machine  *machine.Description
+}
+
+// memory is the part of the simulator that run needs to initialize
+type memory interface {
+	SetMemory(address int, values []int32) error
+}
+
+// output returns where output values are printed; when verifying they are checked instead of printed
+func (run simulation) output() io.Writer {
+	if run.verify {
+		return nil
+	}
+
+	return os.Stdout
+}
+
+// setMemory initializes memory from -i, or from the block's //SIM INPUT header when -i is not given
+func (run simulation) setMemory(sim memory) error {
+	address, values := run.metadata.MemoryAddress, run.metadata.Memory
+	if run.input != "" {
+		var err error
+		address, values, err = m.ParseSimInput(run.input)
+		if err != nil {
+			return err
+		}
+	}
+
+	return sim.SetMemory(address, values)
+}
+
+// simulate runs the IR on the built-in simulator, reading registers from the given register set
+func (run simulation) simulate(IR *ir.Block, registerSet simulator.RegisterSet) {
+	sim := simulator.New(registerSet, run.output())
+	err := run.setMemory(sim)
+	if err == nil {
+		err = sim.Run(IR)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	run.check(sim.Outputs())
+}
+
+// simulateSchedule runs the scheduled bundles cycle by cycle, printing each output value, the cycle count and any operation
+// that read a register before its producer finished
+func (run simulation) simulateSchedule(bundles [][]*m.OperationNode, registerSet simulator.RegisterSet) {
+	sim := simulator.New(registerSet, run.output())
+	err := run.setMemory(sim)
+
+	var report *simulator.CycleReport
+	if err == nil {
+		report, err = sim.RunSchedule(bundles, run.machine)
+	}
+
+	if report != nil {
+		for _, hazard := range report.Hazards {
+			fmt.Fprintf(os.Stderr, "HAZARD: %v\n", hazard)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(report)
+	run.check(sim.Outputs())
+	if len(report.Hazards) > 0 {
+		os.Exit(1)
+	}
+}
+
+// equivalent runs the original block and the transformed IR, then compares their outputs and memory
+func (run simulation) equivalent(IR *ir.Block, registerSet simulator.RegisterSet) {
+	original := simulator.New(simulator.SOURCE, nil)
+	transformed := simulator.New(registerSet, nil)
+	run.runOriginal(original)
+
+	err := run.setMemory(transformed)
+	if err == nil {
+		err = transformed.Run(IR)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: transformed block: %v\n", err)
+		os.Exit(1)
+	}
+
+	run.report(simulator.Compare(original, transformed), len(transformed.Outputs()))
+}
+
+// equivalentSchedule runs the original block and the scheduled bundles cycle by cycle, then compares their outputs and memory
+func (run simulation) equivalentSchedule(bundles [][]*m.OperationNode) {
+	original := simulator.New(simulator.SOURCE, nil)
+	transformed := simulator.New(simulator.VIRTUAL, nil)
+	run.runOriginal(original)
+
+	err := run.setMemory(transformed)
+	if err == nil {
+		_, err = transformed.RunSchedule(bundles, run.machine)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: transformed block: %v\n", err)
+		os.Exit(1)
+	}
+
+	run.report(simulator.Compare(original, transformed), len(transformed.Outputs()))
+}
+
+// the part of the simulator needed to run the original block
+type runner interface {
+	memory
+	Run(IR *ir.Block) error
+}
+
+func (run simulation) runOriginal(sim runner) {
+	err := run.setMemory(sim)
+	if err == nil {
+		err = sim.Run(run.original)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: original block: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (run simulation) report(mismatch *simulator.Mismatch, outputs int) {
+	if mismatch != nil {
+		fmt.Printf("NOT EQUIVALENT: %v\n", mismatch)
+		os.Exit(1)
+	}
+
+	fmt.Printf("EQUIVALENT: all %d outputs and the final memory match\n", outputs)
+}
+
+// check compares the simulated outputs against the block's //OUTPUT header when verifying
+func (run simulation) check(outputs []int32) {
+	if !run.verify {
+		return
+	}
+
+	if !run.metadata.HasOutput {
+		fmt.Fprintln(os.Stderr, "ERROR: -verify needs an //OUTPUT header in the input block")
+		os.Exit(1)
+	}
+
+	expected := run.metadata.ExpectedOutputs
+	for i := range max(len(expected), len(outputs)) {
+		if i >= len(expected) || i >= len(outputs) || expected[i] != outputs[i] {
+			fmt.Printf("FAIL: expected outputs %v but got %v (first difference at output %d)\n", expected, outputs, i+1)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("PASS: all %d outputs match //OUTPUT\n", len(expected))
+}
+
+// check streams the operations of the file without keeping them, printing each diagnostic as soon as it is found.
+// It returns the number of operations parsed and whether the block had no errors
+func check(file io.Reader, chunks, maxErrors int, diagnostics diagnosticPrinter) (int, bool) {
+	blockParser := parser.New(scanner.New(file))
+	if chunks > 1 {
+		input, err := io.ReadAll(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 0, false
+		}
+		blockParser = parser.NewParallel(input, chunks)
+	}
+	blockParser.MaxErrors = maxErrors
+
+	count, ok := 0, true
+	for op, err := range blockParser.Ops() {
+		if op != nil {
+			count++
 			continue
 		}
 
-		fmt.Fprintf(&b, op.String()+"\n")
+		ok = false
+		var diagnostic m.Diagnostic
+		if errors.As(err, &diagnostic) {
+			diagnostics.print([]m.Diagnostic{diagnostic})
+		}
+	}
+
+	return count, ok
+}
+
+// scan prints every token in the file, reporting scanner errors as they are found
+func scan(scanner interface {
+	NextToken() (m.Token, error)
+	WriteToken(w io.Writer, token m.Token)
+}, diagnostics diagnosticPrinter, maxErrors int) int {
+	errorCount := 0
+
+	// one write per token would make printing the tokens of a large block slower than scanning it
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	for {
+		token, err := scanner.NextToken()
+		if err != nil {
+			var diagnostic m.Diagnostic
+			if !errors.As(err, &diagnostic) {
+				diagnostic = m.Diagnostic{Severity: m.ERROR, Line: token.LineNumber, Column: token.StartColumn, Span: token.EndColumn - token.StartColumn + 1, Code: m.CodeInvalidToken, Message: err.Error()}
+			}
+			out.Flush()
+			diagnostics.print([]m.Diagnostic{diagnostic})
+
+			errorCount++
+			if maxErrors > 0 && errorCount >= maxErrors {
+				return errorCount
+			}
+		}
+
+		scanner.WriteToken(out, token)
+		if token.Category == constants.EOF {
+			return errorCount
+		}
+	}
+}
+
+// diagnosticPrinter writes diagnostics to stderr, either rendered with the source line they point at or as JSON
+type diagnosticPrinter struct {
+	json bool
+}
+
+// print writes each diagnostic; as JSON, every diagnostic is one object on its own line
+func (d diagnosticPrinter) print(diagnostics []m.Diagnostic) {
+	encoder := json.NewEncoder(os.Stderr)
+
+	for _, diagnostic := range diagnostics {
+		if d.json {
+			encoder.Encode(diagnostic)
+			continue
+		}
+		fmt.Fprint(os.Stderr, diagnostic.Render())
+	}
+}
+
+// liveReport prints the live range of every VR of the renamed block, then the register pressure at each line as a
+// count and a bar, and MaxLive. A value live on entry has no definition line, and a value never read has no last use.
+func liveReport(renamed *pipeline.RenameResult) string {
+	var b strings.Builder
+	IR := renamed.IR
+
+	line := func(position int) string {
+		if position < 0 {
+			return "-"
+		}
+		return fmt.Sprint(IR.At(position).Line)
+	}
+
+	fmt.Fprintf(&b, "%-6s %-6s %-9s %s\n", "vr", "def", "last use", "length")
+	for _, lr := range renamed.Ranges {
+		def := line(lr.Def)
+		if lr.LiveIn {
+			def = "-"
+		}
+		fmt.Fprintf(&b, "%-6s %-6s %-9s %d\n", fmt.Sprintf("r%d", lr.VR), def, line(lr.LastUse), lr.Length())
+	}
+
+	fmt.Fprintf(&b, "\n%-6s %-8s %s\n", "line", "opcode", "live")
+	for i, op := range IR.Ops() {
+		row := fmt.Sprintf("%-6d %-8s %-5d %s", op.Line, op.Opcode, renamed.Pressure[i], strings.Repeat("#", renamed.Pressure[i]))
+		fmt.Fprintln(&b, strings.TrimRight(row, " "))
+	}
+
+	fmt.Fprintf(&b, "\nMaxLive: %d\n", renamed.MaxLive)
+	return b.String()
+}
+
+// irTable prints each operation of the IR with the SR, VR, PR and NU of its operands
+func irTable(IR *ir.Block, comments bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%-6s %-8s %-26s %-26s %-26s\n", "line", "opcode", "operand 1", "operand 2", "operand 3")
+	for _, op := range IR.Ops() {
+		if comments {
+			writeComments(&b, op)
+		}
+		fmt.Fprintf(&b, "%-6d %-8s %-26s %-26s %-26s\n", op.Line, op.Opcode,
+			operandCell(op.OpOne), operandCell(op.OpTwo), operandCell(op.OpThree))
 	}
 
 	return b.String()
 }
 
-func printSchedule(scheduledBlocks [][]*m.DependenceNode) {
+// operandCell prints one operand of the IR table; constants only have an SR
+func operandCell(o m.Operand) string {
+	if !o.Active {
+		return "[ ]"
+	}
+
+	if o.VR == -1 {
+		return fmt.Sprintf("[ val %d ]", o.SR)
+	}
+
+	return fmt.Sprintf("[ sr%d, vr%s, pr%s, nu%v ]", o.SR, register(o.VR), register(o.PR), o.NU)
+}
+
+// register prints a register number of the IR table, or '-' if it has not been assigned yet
+func register(r int) string {
+	if r == -1 {
+		return "-"
+	}
+
+	return strconv.Itoa(r)
+}
+
+// allocateIR prints each operation of the allocated IR using its physical registers
+func allocateIR(IR *ir.Block, comments bool) string {
 	var b strings.Builder
 
-	for _, blocks := range scheduledBlocks {
-		// build each block
-		for i, block := range blocks {
-			opString := block.Op.String()
-			if i == 0 {
-				fmt.Fprintf(&b, "[ "+opString)
-			} else {
-				fmt.Fprintf(&b, " ;  "+opString)
+	for _, op := range IR.Ops() {
+		if comments {
+			writeComments(&b, op)
+		}
+		fmt.Fprintln(&b, op.PRString())
+	}
+
+	return b.String()
+}
+
+// scheduleIR prints each bundle of the schedule as "[ op ; op ]", one slot per functional unit
+func scheduleIR(bundles [][]*m.OperationNode, comments bool) string {
+	var b strings.Builder
+
+	for _, bundle := range bundles {
+		ops := make([]string, len(bundle))
+		for i, op := range bundle {
+			ops[i] = op.String()
+			if comments {
+				writeComments(&b, op)
 			}
 		}
 
-		fmt.Fprintf(&b, " ]\n")
+		fmt.Fprintf(&b, "[ %s ]\n", strings.Join(ops, " ; "))
 	}
 
-	fmt.Println(b.String())
+	return b.String()
+}
+
+// writeComments prints the comments kept with an operation, one per line, above it
+func writeComments(b *strings.Builder, op *m.OperationNode) {
+	for _, comment := range op.Comments {
+		fmt.Fprintf(b, "//%s\n", comment)
+	}
 }
 
 // below is the renamed output for ex1.txt to add two numbers
@@ -147,4 +702,4 @@ func printSchedule(scheduledBlocks [][]*m.DependenceNode) {
 // add r2,r3 => r0
 // loadI 0 => r1
 // store r0 => r1
-// output => 0
+// output 0
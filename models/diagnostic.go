@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is how serious a diagnostic is; only errors stop a block from being parsed
+type Severity int
+
+const (
+	ERROR   Severity = iota // 0
+	WARNING                 // 1
+)
+
+// MarshalText writes a severity by name, so diagnostics read naturally as JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "error":
+		*s = ERROR
+	case "warning":
+		*s = WARNING
+	default:
+		return fmt.Errorf("unknown severity %q", text)
+	}
+
+	return nil
+}
+
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", s)
+	}
+}
+
+// the codes of the diagnostics the scanner, parser and validator report, grouped by the stage that finds them
+const (
+	CodeInvalidToken    = "E101" // the scanner could not recognize a token
+	CodeUnexpectedToken = "E201" // the parser found a token that cannot come next in the operation
+	CodeInvalidOperand  = "E202" // a register or constant could not be read
+	CodeUndefinedLabel  = "E301" // a branch names a label that is never defined
+	CodeDuplicateLabel  = "E302" // a label is defined more than once
+	CodeDanglingLabel   = "E303" // a label is not followed by an operation
+	CodeInvalidHeader   = "E401" // a //SIM INPUT or //OUTPUT header is malformed
+
+	// the validator's codes are warnings, unless it is strict
+	CodeUndefinedRead    = "W501" // a register is read before any operation could have written it
+	CodeConstantRange    = "W502" // a loadI or output constant does not fit in 32 bits
+	CodeRegisterTooLarge = "W503" // a register number is so large that the renamer would allocate huge tables
+)
+
+// Diagnostic is a problem found in the input, located at a line and, when it is about one token, a span of columns
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"` // the 1-based column the span starts at, or 0 if the diagnostic is about the whole line
+	Span     int      `json:"span"`   // the number of characters the diagnostic covers, starting at Column
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Source   string   `json:"source,omitempty"` // the text of the line, if it is known
+}
+
+// Error prints the diagnostic on one line, the way the parser has always reported errors: "ERROR 12: message"
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s %d: %s", strings.ToUpper(d.Severity.String()), d.Line, d.Message)
+}
+
+// Render prints the diagnostic along with the line it was found on and a caret under the span it covers
+func (d Diagnostic) Render() string {
+	var b strings.Builder
+
+	location := fmt.Sprintf("%d", d.Line)
+	if d.Column > 0 {
+		location = fmt.Sprintf("%d:%d", d.Line, d.Column)
+	}
+	fmt.Fprintf(&b, "%s %s: %s [%s]\n", strings.ToUpper(d.Severity.String()), location, d.Message, d.Code)
+
+	if d.Source == "" {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "    %s\n", d.Source)
+
+	if d.Column > 0 && d.Column <= len(d.Source)+1 {
+		// keep the tabs before the span so the caret lines up under it
+		var indent strings.Builder
+		for _, c := range d.Source[:d.Column-1] {
+			if c == '\t' {
+				indent.WriteRune('\t')
+			} else {
+				indent.WriteRune(' ')
+			}
+		}
+		fmt.Fprintf(&b, "    %s%s\n", indent.String(), strings.Repeat("^", max(d.Span, 1)))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Metadata holds the test information found in a block's //SIM INPUT and //OUTPUT header comments
+type Metadata struct {
+	HasSimInput     bool
+	MemoryAddress   int     // the first address written by -i
+	Memory          []int32 // the words written starting at MemoryAddress
+	HasOutput       bool
+	ExpectedOutputs []int32
+}
+
+// ParseSimInput converts a sim style input string ("-i 1024 1 2 3" or "1024 1 2 3") into a starting address and its values
+func ParseSimInput(input string) (int, []int32, error) {
+	fields := strings.Fields(input)
+	if len(fields) > 0 && fields[0] == "-i" {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 {
+		return 0, nil, nil
+	}
+
+	address, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid initial memory address %q: %w", fields[0], err)
+	}
+
+	values, err := ParseValues(strings.Join(fields[1:], " "))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return address, values, nil
+}
+
+// ParseValues converts a whitespace separated list of integers, as found after //OUTPUT:, into values
+func ParseValues(input string) ([]int32, error) {
+	fields := strings.Fields(input)
+	values := make([]int32, 0, len(fields))
+
+	for _, field := range fields {
+		v, err := strconv.ParseInt(field, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q: %w", field, err)
+		}
+		values = append(values, int32(v))
+	}
+
+	return values, nil
+}
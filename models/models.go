@@ -2,14 +2,17 @@ package models
 
 import (
 	"fmt"
+	"strings"
 )
 
 type SyntacticCategory int
 
 type Token struct {
-	Category   SyntacticCategory
-	Lexeme     string
-	LineNumber int
+	Category    SyntacticCategory
+	Lexeme      string
+	LineNumber  int
+	StartColumn int // the 1-based column of the token's first character, or 0 for EOF
+	EndColumn   int // the 1-based column of the token's last character
 }
 
 func (t Token) String() string {
@@ -22,47 +25,101 @@ type Operand struct {
 	PR     int
 	NU     float64
 	Active bool
+
+	StartColumn int // the 1-based columns the register or constant was written at in the source, or 0 if it was not
+	EndColumn   int
 }
 
 func (op Operand) String() string {
 	if !op.Active {
 		return "[ Inactive Operand ]"
 	}
-	return fmt.Sprintf("[SR=%d, VR=%d, NU=%d, Active=%t]", op.SR, op.VR, op.NU, op.Active)
+	return fmt.Sprintf("[SR=%d, VR=%d, NU=%v, Active=%t]", op.SR, op.VR, op.NU, op.Active)
 }
 
 type OperationNode struct {
+	ID      int // the operation's ID in its ir.Block, which stays the same as operations are inserted and removed around it
 	Line    int
 	Opcode  string
 	OpOne   Operand
 	OpTwo   Operand
 	OpThree Operand
+
+	Label   string   // the label defined on this operation, if any
+	Targets []string // the labels a jumpI or cbr branches to, in the order they are written
+
+	// the text after the '//' of the comments written on the lines before this operation and at the end of its line
+	Comments []string
+
+	Source string // the text of the line the operation was written on, so later passes can point at it in diagnostics
 }
 
 func (op OperationNode) String() string {
+	return op.format(func(o Operand) int { return o.VR })
+}
+
+// PRString prints the operation using the physical registers assigned by the allocator
+func (op OperationNode) PRString() string {
+	return op.format(func(o Operand) int { return o.PR })
+}
+
+// format prints the operation, using reg to pick which register name (VR or PR) is shown for each operand
+func (op OperationNode) format(reg func(Operand) int) string {
+	if op.Label != "" {
+		return op.Label + ": " + op.formatOperation(reg)
+	}
+
+	return op.formatOperation(reg)
+}
+
+func (op OperationNode) formatOperation(reg func(Operand) int) string {
 	switch op.Opcode {
 	// ARITH (two uses, one def)
-	case "add", "mult", "sub", "lshift", "rshift": // add rA,rB => rC
+	case "add", "sub", "mult", "div", "lshift", "rshift", "loadAO",
+		"cmp_LT", "cmp_LE", "cmp_EQ", "cmp_GE", "cmp_GT", "cmp_NE": // add rA,rB => rC
 		return fmt.Sprintf("%s r%d,r%d => r%d",
-			op.Opcode, op.OpOne.VR, op.OpTwo.VR, op.OpThree.VR)
+			op.Opcode, reg(op.OpOne), reg(op.OpTwo), reg(op.OpThree))
 
-	// LOAD variants
-	case "load": // load rAddr => rDst
-		return fmt.Sprintf("load r%d => r%d",
-			op.OpOne.VR, op.OpThree.VR)
+	// IMMEDIATE (one use and a constant, one def)
+	case "addI", "subI", "multI", "divI", "lshiftI", "rshiftI", "loadAI": // addI rA,c => rC
+		return fmt.Sprintf("%s r%d,%d => r%d",
+			op.Opcode, reg(op.OpOne), op.OpTwo.SR, reg(op.OpThree))
+
+	// LOAD variants and copies
+	case "load", "i2i": // load rAddr => rDst
+		return fmt.Sprintf("%s r%d => r%d",
+			op.Opcode, reg(op.OpOne), reg(op.OpThree))
 
 	case "loadI":
 		return fmt.Sprintf("loadI %d => r%d",
-			op.OpOne.SR, op.OpThree.VR)
+			op.OpOne.SR, reg(op.OpThree))
 
-	// STORE (two uses, no def)
+	// STORE (two or three uses, no def)
 	case "store": // store rVal => rAddr
 		return fmt.Sprintf("store r%d => r%d",
-			op.OpOne.VR, op.OpThree.VR)
+			reg(op.OpOne), reg(op.OpThree))
+
+	case "storeAI": // storeAI rVal => rAddr,c
+		return fmt.Sprintf("storeAI r%d => r%d,%d",
+			reg(op.OpOne), reg(op.OpThree), op.OpTwo.SR)
+
+	case "storeAO": // storeAO rVal => rAddr,rOffset
+		return fmt.Sprintf("storeAO r%d => r%d,r%d",
+			reg(op.OpOne), reg(op.OpThree), reg(op.OpTwo))
 
 	// OUTPUT
-	case "output": // output => rX
-		return fmt.Sprintf("output => %d", op.OpThree.SR)
+	case "output": // output X
+		return fmt.Sprintf("output %d", op.OpThree.SR)
+
+	// BRANCHES
+	case "jumpI": // jumpI -> L1
+		return fmt.Sprintf("jumpI -> %s", strings.Join(op.Targets, ", "))
+
+	case "jump": // jump -> rTarget
+		return fmt.Sprintf("jump -> r%d", reg(op.OpOne))
+
+	case "cbr": // cbr rCond -> L1, L2
+		return fmt.Sprintf("cbr r%d -> %s", reg(op.OpOne), strings.Join(op.Targets, ", "))
 
 	// NOP
 	case "nop":
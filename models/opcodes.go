@@ -0,0 +1,80 @@
+package models
+
+// OperandKind is what one of the OpOne, OpTwo and OpThree slots of an operation holds
+type OperandKind int
+
+const (
+	UNUSED    OperandKind = iota // 0
+	USE                          // 1; a register that is read
+	DEF                          // 2; a register that is written
+	IMMEDIATE                    // 3; a constant
+)
+
+// Opcode describes the operands of an ILOC opcode and how it accesses memory
+type Opcode struct {
+	Operands [3]OperandKind
+
+	// a load reads memory and a store writes it at an address held in registers. output also reads memory, but at a
+	// constant address, and is ordered against other outputs instead.
+	ReadsMemory  bool
+	WritesMemory bool
+
+	// a branch ends its basic block; jumpI and cbr name their targets, while jump goes to the address in a register
+	Branch bool
+}
+
+var arithmetic = Opcode{Operands: [3]OperandKind{USE, USE, DEF}}
+var immediate = Opcode{Operands: [3]OperandKind{USE, IMMEDIATE, DEF}}
+
+// Opcodes describes every ILOC opcode the front end accepts
+var Opcodes = map[string]Opcode{
+	"nop":    {},
+	"output": {Operands: [3]OperandKind{UNUSED, UNUSED, IMMEDIATE}},
+	"loadI":  {Operands: [3]OperandKind{IMMEDIATE, UNUSED, DEF}},
+	"i2i":    {Operands: [3]OperandKind{USE, UNUSED, DEF}},
+
+	// load r1 => r2, loadAI r1, c2 => r3 and loadAO r1, r2 => r3
+	"load":   {Operands: [3]OperandKind{USE, UNUSED, DEF}, ReadsMemory: true},
+	"loadAI": {Operands: [3]OperandKind{USE, IMMEDIATE, DEF}, ReadsMemory: true},
+	"loadAO": {Operands: [3]OperandKind{USE, USE, DEF}, ReadsMemory: true},
+
+	// store r1 => r2, storeAI r1 => r2, c3 and storeAO r1 => r2, r3; the address registers are in OpThree and OpTwo
+	"store":   {Operands: [3]OperandKind{USE, UNUSED, USE}, WritesMemory: true},
+	"storeAI": {Operands: [3]OperandKind{USE, IMMEDIATE, USE}, WritesMemory: true},
+	"storeAO": {Operands: [3]OperandKind{USE, USE, USE}, WritesMemory: true},
+
+	"add":    arithmetic,
+	"sub":    arithmetic,
+	"mult":   arithmetic,
+	"div":    arithmetic,
+	"lshift": arithmetic,
+	"rshift": arithmetic,
+	"cmp_LT": arithmetic,
+	"cmp_LE": arithmetic,
+	"cmp_EQ": arithmetic,
+	"cmp_GE": arithmetic,
+	"cmp_GT": arithmetic,
+	"cmp_NE": arithmetic,
+
+	"jumpI": {Branch: true},
+	"jump":  {Operands: [3]OperandKind{USE, UNUSED, UNUSED}, Branch: true},
+	"cbr":   {Operands: [3]OperandKind{USE, UNUSED, UNUSED}, Branch: true},
+
+	"addI":    immediate,
+	"subI":    immediate,
+	"multI":   immediate,
+	"divI":    immediate,
+	"lshiftI": immediate,
+	"rshiftI": immediate,
+}
+
+// IsRegister reports whether operand i (0 for OpOne through 2 for OpThree) of the opcode is a register
+func IsRegister(opcode string, i int) bool {
+	kind := Opcodes[opcode].Operands[i]
+	return kind == USE || kind == DEF
+}
+
+// IsDefinition reports whether operand i of the opcode is a register that the operation writes
+func IsDefinition(opcode string, i int) bool {
+	return Opcodes[opcode].Operands[i] == DEF
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"fmt"
+
+	c "github.com/bivguy/Comp412/constants"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// ParseSchedule parses a scheduled block, written one bundle per line as "[ op ; op ]" the way PrintSchedule prints it.
+// It returns the operations issued in each cycle; every operation of a bundle has the bundle's line number. A label on
+// any operation of a bundle names the bundle, so branches go to it. Errors are reported the same way as by Parse.
+func (p *parser) ParseSchedule() ([][]*m.OperationNode, []m.Diagnostic, error) {
+	var schedule [][]*m.OperationNode
+
+	p.bundles = true
+	defer func() { p.bundles = false }()
+
+	token := p.nextOperationToken()
+	for token.Category != c.EOF && !p.stopped() {
+		bundle, err := p.parseBundle(token)
+
+		if err != nil {
+			p.report(p.diagnose(m.CodeUnexpectedToken, err))
+		} else {
+			schedule = append(schedule, bundle)
+		}
+
+		p.currentOperation = m.OperationNode{}
+
+		token = p.nextOperationToken()
+	}
+
+	return schedule, p.diagnostics, p.finish()
+}
+
+// parseBundle parses the operations of one bundle, given the token that should open it
+func (p *parser) parseBundle(token m.Token) ([]*m.OperationNode, error) {
+	if token.Category != c.LBRACKET {
+		p.scanner.SetNextLine()
+		return nil, fmt.Errorf("expected a bundle starting with '[' but got %v", token)
+	}
+
+	var bundle []*m.OperationNode
+	for {
+		token := p.nextCorrectToken()
+		if token.Category == c.LABEL {
+			if err := p.defineLabel(token); err != nil {
+				p.scanner.SetNextLine()
+				return nil, err
+			}
+			token = p.nextCorrectToken()
+		}
+
+		err := p.finishOperation(token)
+		if err != nil {
+			p.scanner.SetNextLine()
+			return nil, err
+		}
+
+		op := p.currentOperation
+		bundle = append(bundle, &op)
+		p.currentOperation = m.OperationNode{}
+
+		if p.terminator.Category == c.RBRACKET {
+			break
+		}
+	}
+
+	// nothing but a comment may follow the end of a bundle
+	end := p.nextCorrectToken()
+	if end.Category != c.EOL && end.Category != c.EOF && end.Category != c.COMMENT {
+		p.scanner.SetNextLine()
+		return nil, fmt.Errorf("expected the end of the line after ']' but got %v", end)
+	}
+	// a comment after the ']' belongs to the bundle on its line, not to the next one
+	if end.Category == c.COMMENT {
+		p.comment(&bundle[len(bundle)-1].Comments)
+	}
+
+	return bundle, nil
+}
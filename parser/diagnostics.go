@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"errors"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+// codedError is an error that knows which diagnostic code it should be reported with
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e codedError) Unwrap() error {
+	return e.err
+}
+
+// position returns a diagnostic located at the token the scanner returned last, without a code or message yet
+func (p *parser) position() m.Diagnostic {
+	start, end := p.scanner.Columns()
+
+	return m.Diagnostic{
+		Severity: m.ERROR,
+		Line:     p.scanner.GetCurrentLine(),
+		Column:   start,
+		Span:     end - start + 1,
+		Source:   p.scanner.Source(),
+	}
+}
+
+// locate fills in the code and message of a diagnostic made by position
+func locate(at m.Diagnostic, code string, err error) m.Diagnostic {
+	at.Code = code
+	at.Message = err.Error()
+	return at
+}
+
+// diagnose turns an error into a diagnostic. Scanner errors and errors that were located where they were found are
+// kept as they are; any other error is placed at the token the scanner returned last.
+func (p *parser) diagnose(code string, err error) m.Diagnostic {
+	var d m.Diagnostic
+	if errors.As(err, &d) {
+		return d
+	}
+
+	var coded codedError
+	if errors.As(err, &coded) {
+		code = coded.code
+	}
+
+	return locate(p.position(), code, err)
+}
+
+// report records a diagnostic; an error means the input cannot be used
+func (p *parser) report(d m.Diagnostic) {
+	p.diagnostics = append(p.diagnostics, d)
+	if d.Severity == m.ERROR {
+		p.ErrorFound = true
+		p.errors++
+	}
+}
+
+// stopped reports whether the parser has found as many errors as it may
+func (p *parser) stopped() bool {
+	return p.MaxErrors > 0 && p.errors >= p.MaxErrors
+}
+
+// finish runs the checks that need the whole input and returns the error for the diagnostics found, if any. Once the
+// parser has stopped early, a label may be missing only because it was never reached, so labels are not checked.
+func (p *parser) finish() error {
+	if p.stopped() {
+		return ErrTooManyErrors
+	}
+
+	p.checkLabels()
+	if p.ErrorFound {
+		return ErrParse
+	}
+
+	return nil
+}
@@ -1,15 +1,16 @@
 package parser
 
 import (
-	"container/list"
+	"math"
 
+	"github.com/bivguy/Comp412/ir"
 	m "github.com/bivguy/Comp412/models"
 )
 
 type TestCase struct {
 	description   string
 	input         string
-	expectedIR    *list.List
+	expectedIR    *ir.Block
 	expectedError bool
 }
 
@@ -19,12 +20,92 @@ type PerformanceTestCase struct {
 	MaximumTimeMs int64
 }
 
-func createOperand(SR int) m.Operand {
+type MetadataTestCase struct {
+	description string
+	input       string
+	expected    m.Metadata
+}
+
+type ScheduleTestCase struct {
+	description     string
+	input           string
+	expectedOpcodes [][]string
+	expectedError   bool
+}
+
+// OperandTestCase parses a single operation and checks which SR lands in each operand slot; -1 marks an unused slot.
+// If expectedColumns is set, the start and end column of each operand are checked too
+type OperandTestCase struct {
+	input           string
+	expectedOpcode  string
+	expectedSRs     [3]int
+	expectedColumns [3][2]int
+}
+
+// LabelTestCase parses a program and checks the label and branch targets of each operation
+type LabelTestCase struct {
+	description     string
+	input           string
+	expectedLabels  []string
+	expectedTargets [][]string
+	expectedError   bool
+}
+
+// DiagnosticTestCase parses a program and checks where each diagnostic points and which code it has
+type DiagnosticTestCase struct {
+	description string
+	input       string
+	expected    []m.Diagnostic // only the Line, Column, Span and Code of each are compared
+	rendered    string         // how the first diagnostic renders, if set
+}
+
+// MaxErrorsTestCase parses a program with an error limit and checks the lines of the diagnostics reported before it stopped
+type MaxErrorsTestCase struct {
+	description   string
+	input         string
+	maxErrors     int
+	expectedErr   error
+	expectedLines []int
+}
+
+// CommentTestCase parses a program and checks the comments kept with each operation
+type CommentTestCase struct {
+	description      string
+	input            string
+	bundles          bool
+	expectedComments [][]string
+	expectedOutputs  []int32 // the outputs of the //OUTPUT header, if one is expected
+}
+
+// ParallelTestCase parses an input both in one piece and in chunks, from the file input or else from text, and checks
+// that both give the same results
+type ParallelTestCase struct {
+	description string
+	input       string
+	text        string
+	maxErrors   int
+}
+
+// OpsTestCase streams a program with Ops and checks what is yielded in order: the opcode of each operation, the code
+// of each diagnostic, or "stopped" for ErrTooManyErrors. If breakAfter is set, the loop stops after that many yields
+type OpsTestCase struct {
+	description string
+	input       string
+	maxErrors   int
+	breakAfter  int
+	expected    []string
+}
+
+// createOperand builds an operand the way the parser leaves it, before renaming, spanning the given columns
+func createOperand(SR int, startColumn int, endColumn int) m.Operand {
 	return m.Operand{
-		SR:     SR,
-		Active: true,
-		VR:     -1,
-		NU:     -1,
+		SR:          SR,
+		Active:      true,
+		VR:          -1,
+		PR:          -1,
+		NU:          math.Inf(1),
+		StartColumn: startColumn,
+		EndColumn:   endColumn,
 	}
 }
 
@@ -32,94 +113,100 @@ var simpleTestCases = []TestCase{
 	{
 		description: "simple MEMOP test",
 		input:       "parser_tests/simple_tests/test_1.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    1,
 				Opcode:  "store",
-				OpOne:   createOperand(1),
-				OpThree: createOperand(2),
+				OpOne:   createOperand(1, 7, 8),
+				OpThree: createOperand(2, 13, 14),
+				Source:  "store r1 => r2",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 	{
 		description: "simple LOADI test",
 		input:       "parser_tests/simple_tests/test_2.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    1,
 				Opcode:  "loadI",
-				OpOne:   createOperand(17),
-				OpThree: createOperand(1),
+				OpOne:   createOperand(17, 7, 8),
+				OpThree: createOperand(1, 13, 14),
+				Source:  "loadI 17 => r1",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 	{
 		description: "simple ARITHOP add test",
 		input:       "parser_tests/simple_tests/test_3.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    1,
 				Opcode:  "add",
-				OpOne:   createOperand(1),
-				OpTwo:   createOperand(1),
-				OpThree: createOperand(2),
+				OpOne:   createOperand(1, 5, 6),
+				OpTwo:   createOperand(1, 8, 9),
+				OpThree: createOperand(2, 14, 15),
+				Source:  "add r1,r1 => r2",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 	{
 		description: "simple OUTPUT test",
 		input:       "parser_tests/simple_tests/test_4.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    1,
 				Opcode:  "output",
-				OpThree: createOperand(2214),
+				OpThree: createOperand(2214, 8, 11),
+				Source:  "output 2214",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 	{
 		description: "simple NOP test",
 		input:       "parser_tests/simple_tests/test_5.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:   1,
 				Opcode: "nop",
+				Source: "nop",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 	{
 		description: "testing some invalid file",
 		input:       "parser_tests/simple_tests/test_6.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    3,
 				Opcode:  "output",
-				OpThree: createOperand(4),
+				OpThree: createOperand(4, 8, 8),
+				Source:  "output 4",
 			})
 
-			return ir
+			return IR
 		}(), expectedError: true,
 	},
 }
@@ -128,11 +215,11 @@ var complexTestCases = []TestCase{
 	{
 		description: "t11",
 		input:       "parser_tests/complex_tests/t11.i.txt",
-		expectedIR: func() *list.List {
-			ir := list.New()
+		expectedIR: func() *ir.Block {
+			IR := ir.New()
 
 			//   loadI 8 => r1
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    2,
 				Opcode:  "loadI",
 				OpOne:   m.Operand{SR: 8},
@@ -140,7 +227,7 @@ var complexTestCases = []TestCase{
 			})
 
 			//   store r1 => r1
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    3,
 				Opcode:  "store",
 				OpOne:   m.Operand{SR: 1},
@@ -148,13 +235,13 @@ var complexTestCases = []TestCase{
 			})
 
 			//   store r1 => r1
-			ir.PushBack(m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    4,
 				Opcode:  "output",
 				OpThree: m.Operand{SR: 4},
 			})
 
-			return ir
+			return IR
 		}(), expectedError: false,
 	},
 }
@@ -166,3 +253,285 @@ var performanceTestCases = []PerformanceTestCase{
 		MaximumTimeMs: 200,
 	},
 }
+
+var metadataTestCases = []MetadataTestCase{
+	{
+		description: "no headers",
+		input:       "../test_files/ex1.txt",
+		expected:    m.Metadata{},
+	},
+	{
+		description: "empty sim input",
+		input:       "../test_files/report2.i",
+		expected: m.Metadata{
+			HasSimInput:     true,
+			HasOutput:       true,
+			ExpectedOutputs: []int32{1, 3, 6, 10, 15, 21, 28, 36, 45, 55},
+		},
+	},
+	{
+		description: "initial memory",
+		input:       "../test_files/report16.i",
+		expected: m.Metadata{
+			HasSimInput:     true,
+			MemoryAddress:   1024,
+			Memory:          []int32{1056, 1052, 1048, 1044, 1040, 1036, 1032, 1028, 1024},
+			HasOutput:       true,
+			ExpectedOutputs: []int32{1032, 1028, 1024, 1036, 1056},
+		},
+	},
+}
+
+var scheduleTestCases = []ScheduleTestCase{
+	{
+		description:     "two bundles",
+		input:           "parser_tests/simple_tests/test_7.txt",
+		expectedOpcodes: [][]string{{"loadI", "add"}, {"nop", "output"}},
+	},
+	{
+		description:   "operations outside of a bundle",
+		input:         "parser_tests/simple_tests/test_2.txt",
+		expectedError: true,
+	},
+}
+
+var operandTestCases = []OperandTestCase{
+	{"loadAI r1, 4 => r2", "loadAI", [3]int{1, 4, 2}, [3][2]int{}},
+	{"loadAO r1, r3 => r2", "loadAO", [3]int{1, 3, 2}, [3][2]int{}},
+	{"storeAI r2 => r1, 8", "storeAI", [3]int{2, 8, 1}, [3][2]int{}},
+	{"storeAO r2 => r1, r3", "storeAO", [3]int{2, 3, 1}, [3][2]int{}},
+	{"i2i r4 => r5", "i2i", [3]int{4, -1, 5}, [3][2]int{}},
+	{"addI r1, 12 => r2", "addI", [3]int{1, 12, 2}, [3][2]int{}},
+	{"rshiftI r1, 2 => r2", "rshiftI", [3]int{1, 2, 2}, [3][2]int{}},
+	{"div r1, r2 => r3", "div", [3]int{1, 2, 3}, [3][2]int{}},
+	{"cmp_LE r1, r2 => r3", "cmp_LE", [3]int{1, 2, 3}, [3][2]int{}},
+	{"  storeAI r12 => r1, 1024", "storeAI", [3]int{12, 1024, 1}, [3][2]int{{11, 13}, {22, 25}, {18, 19}}},
+	{"output 8", "output", [3]int{-1, -1, 8}, [3][2]int{{}, {}, {8, 8}}},
+}
+
+var labelTestCases = []LabelTestCase{
+	{
+		description:     "labels and branches",
+		input:           "L1: loadI 1 => r1\ncbr r1 -> L1, L2\nL2: jumpI -> L1\n",
+		expectedLabels:  []string{"L1", "", "L2"},
+		expectedTargets: [][]string{nil, {"L1", "L2"}, {"L1"}},
+	},
+	{
+		description:     "label on its own line",
+		input:           "L_loop:\n// a comment\njump -> r1\n",
+		expectedLabels:  []string{"L_loop"},
+		expectedTargets: [][]string{nil},
+	},
+	{
+		description:   "undefined label",
+		input:         "jumpI -> L9\n",
+		expectedError: true,
+	},
+	{
+		description:   "label defined twice",
+		input:         "L1: nop\nL1: nop\n",
+		expectedError: true,
+	},
+	{
+		description:   "label without an operation",
+		input:         "nop\nL1:\n",
+		expectedError: true,
+	},
+	{
+		description:   "label without a colon",
+		input:         "L1 nop\n",
+		expectedError: true,
+	},
+}
+
+var diagnosticTestCases = []DiagnosticTestCase{
+	{
+		description: "valid block",
+		input:       "loadI 4 => r1\noutput 4\n",
+	},
+	{
+		description: "unexpected token",
+		input:       "loadI 12 => r1\nadd r1, 5 => r2\n",
+		expected:    []m.Diagnostic{{Line: 2, Column: 9, Span: 1, Code: m.CodeUnexpectedToken}},
+		rendered: "ERROR 2:9: parser encountered an error at line 2: expected a token of type REGISTER but got one of type CONSTANT [E201]\n" +
+			"    add r1, 5 => r2\n" +
+			"            ^\n",
+	},
+	{
+		description: "scanner error after a tab",
+		input:       "\tlaod r1 => r2\n",
+		expected:    []m.Diagnostic{{Line: 1, Column: 2, Span: 2, Code: m.CodeInvalidToken}},
+		rendered: "ERROR 1:2: invalid instruction: letter 'o' or 's' expected but found a [E101]\n" +
+			"    \tlaod r1 => r2\n" +
+			"    \t^^\n",
+	},
+	{
+		description: "constant too large",
+		input:       "loadI 99999999999999999999 => r1\n",
+		expected:    []m.Diagnostic{{Line: 1, Column: 7, Span: 20, Code: m.CodeInvalidOperand}},
+	},
+	{
+		description: "malformed header",
+		input:       "//OUTPUT: x\nnop\n",
+		expected:    []m.Diagnostic{{Line: 1, Column: 1, Span: 11, Code: m.CodeInvalidHeader}},
+	},
+	{
+		description: "label errors",
+		input:       "L1: jumpI -> L9\nL1: nop\nL2:\n",
+		expected: []m.Diagnostic{
+			{Line: 2, Column: 1, Span: 2, Code: m.CodeDuplicateLabel},
+			{Line: 3, Column: 1, Span: 2, Code: m.CodeDanglingLabel},
+			{Line: 1, Column: 14, Span: 2, Code: m.CodeUndefinedLabel},
+		},
+	},
+}
+
+var maxErrorsTestCases = []MaxErrorsTestCase{
+	{
+		description:   "no limit",
+		input:         "add r1 => r2\nloadI r1 => r2\nnop\nlaod r1 => r2\n",
+		expectedErr:   ErrParse,
+		expectedLines: []int{1, 2, 4},
+	},
+	{
+		description:   "stops at the limit",
+		input:         "add r1 => r2\nloadI r1 => r2\nnop\nlaod r1 => r2\n",
+		maxErrors:     2,
+		expectedErr:   ErrTooManyErrors,
+		expectedLines: []int{1, 2},
+	},
+	{
+		description:   "fewer errors than the limit",
+		input:         "add r1 => r2\nnop\n",
+		maxErrors:     2,
+		expectedErr:   ErrParse,
+		expectedLines: []int{1},
+	},
+	{
+		description:   "label errors are not checked once stopped",
+		input:         "jumpI -> L9\nadd r1 => r2\n",
+		maxErrors:     1,
+		expectedErr:   ErrTooManyErrors,
+		expectedLines: []int{2},
+	},
+	{
+		description:   "a scanner error after the parser error that reaches the limit",
+		input:         "add r1 r2 => r3\nx\nnop\n",
+		maxErrors:     1,
+		expectedErr:   ErrTooManyErrors,
+		expectedLines: []int{1},
+	},
+	{
+		description:   "a scanner error after a label error that reaches the limit",
+		input:         "L1: nop\nL1: nop\nx\n",
+		maxErrors:     1,
+		expectedErr:   ErrTooManyErrors,
+		expectedLines: []int{2},
+	},
+	{
+		description: "valid block",
+		input:       "nop\n",
+		maxErrors:   1,
+	},
+}
+
+var commentTestCases = []CommentTestCase{
+	{
+		description:      "comments before and after operations",
+		input:            "//OUTPUT: 4\n// first\n\n//second\nloadI 4 => r1 // into r1\noutput 4\n",
+		expectedComments: [][]string{{"OUTPUT: 4", " first", "second", " into r1"}, nil},
+	},
+	{
+		description:      "comment before a label",
+		input:            "// loop\nL1:\n// body\nnop\n",
+		expectedComments: [][]string{{" loop", " body"}},
+	},
+	{
+		description:      "comment after the last operation",
+		input:            "nop\n// done\n",
+		expectedComments: [][]string{nil},
+	},
+	{
+		description:      "comments in a schedule",
+		input:            "// start\n[ loadI 4 => r1 ; nop ] // one\n[ output 4 ]\n",
+		bundles:          true,
+		expectedComments: [][]string{{" start"}, {" one"}, nil},
+	},
+	{
+		description:      "a header at the end of an operation's line",
+		input:            "loadI 4 => r1 //OUTPUT: 4\noutput 4\n",
+		expectedComments: [][]string{{"OUTPUT: 4"}, nil},
+		expectedOutputs:  []int32{4},
+	},
+	{
+		description:      "a header at the end of a bundle's line",
+		input:            "[ loadI 4 => r1 ; nop ] //OUTPUT: 4\n[ output 4 ]\n",
+		bundles:          true,
+		expectedComments: [][]string{nil, {"OUTPUT: 4"}, nil},
+		expectedOutputs:  []int32{4},
+	},
+}
+
+var parallelTestCases = []ParallelTestCase{
+	{description: "t128k", input: "parser_tests/complex_tests/t128k.i.txt"},
+	{description: "report2 with headers and comments", input: "../test_files/report2.i"},
+	{description: "branches", input: "../test_files/branches.i"},
+	{description: "full opcode set", input: "../test_files/full_iloc.i"},
+	{description: "scanner and parser errors", input: "../test_files/t1.i.txt"},
+	{description: "error limit", input: "../test_files/t1.i.txt", maxErrors: 2},
+	{
+		description: "labels across chunks",
+		text: "// a loop\nL1:\n// its body\nloadI 1 => r1 // one\nnop\nnop\nL2: cbr r1 -> L1, L3\nnop\nnop\nnop\n" +
+			"L3:\nnop\njumpI -> L2\n",
+	},
+	{
+		description: "label errors across chunks",
+		text: "L1: loadI 1 => r1\nnop\nnop\nL2:\n// body\nnop\nnop\nL1: nop\nadd r1 => r2\nL3: cbr r1 -> L2, L9\n" +
+			"nop\nL2: nop\nnop\nnop\nnop\nL4:\n",
+	},
+	{
+		description: "error limit with parser and scanner errors across chunks",
+		text: "loadI 1 => r1\nadd r1 r2 => r3\nx\nnop\nnop\nloadI => r1\nnop\nnop\nstoreabc\nnop\nnop\n" +
+			"add r1, r2 =>\nnop\nnop\ny\nnop\n",
+		maxErrors: 3,
+	},
+	{
+		description: "error limit at a parser error followed by a scanner error",
+		text:        "add r1 r2 => r3\nx\nnop\nnop\nnop\nnop\n",
+		maxErrors:   1,
+	},
+	{
+		description: "no newline at the end",
+		text:        "loadI 4 => r1\nnop\noutput 4",
+	},
+}
+
+var opsTestCases = []OpsTestCase{
+	{
+		description: "valid block",
+		input:       "loadI 4 => r1\nadd r1, r1 => r2\noutput 4\n",
+		expected:    []string{"loadI", "add", "output"},
+	},
+	{
+		description: "errors are yielded where they are found",
+		input:       "loadI 4 => r1\nadd r1 => r2\nL1: nop\nlaod r1 => r2\noutput 4\n",
+		expected:    []string{"loadI", m.CodeUnexpectedToken, "nop", m.CodeInvalidToken, "output"},
+	},
+	{
+		description: "label errors come at the end",
+		input:       "jumpI -> L9\nnop\nL1:\n",
+		expected:    []string{"jumpI", "nop", m.CodeDanglingLabel, m.CodeUndefinedLabel},
+	},
+	{
+		description: "stops at the limit",
+		input:       "add r1 => r2\nnop\nloadI r1 => r2\nnop\nlaod r1 => r2\n",
+		maxErrors:   2,
+		expected:    []string{m.CodeUnexpectedToken, "nop", m.CodeUnexpectedToken, "stopped"},
+	},
+	{
+		description: "break out of the loop",
+		input:       "loadI 4 => r1\nadd r1 => r2\noutput 4\n",
+		breakAfter:  2,
+		expected:    []string{"loadI", m.CodeUnexpectedToken},
+	},
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"fmt"
+
+	c "github.com/bivguy/Comp412/constants"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// target is a label a branch goes to, along with where the branch names it
+type target struct {
+	label string
+	at    m.Diagnostic
+}
+
+// defineLabel reads the ':' after a label at the start of an operation. The label belongs to the next operation,
+// which may follow on the same line or on a later one.
+func (p *parser) defineLabel(token m.Token) error {
+	at := p.position()
+
+	colon := p.nextCorrectToken()
+	if colon.Category != c.COLON {
+		p.scanner.SetNextLine()
+		return parserError(colon.Category, c.COLON, colon)
+	}
+
+	if p.label != "" {
+		return locate(at, m.CodeUnexpectedToken, fmt.Errorf("label %s follows label %s, but an operation can only have one label", token.Lexeme, p.label))
+	}
+	if line, ok := p.labels[token.Lexeme]; ok {
+		return duplicateLabel(token.Lexeme, at, line)
+	}
+
+	p.labels[token.Lexeme] = token.LineNumber
+	p.definitions = append(p.definitions, target{label: token.Lexeme, at: at})
+	p.label = token.Lexeme
+	p.labelAt = at
+	return nil
+}
+
+// duplicateLabel is the error for a label defined at the given position that was already defined at line
+func duplicateLabel(label string, at m.Diagnostic, line int) m.Diagnostic {
+	return locate(at, m.CodeDuplicateLabel, fmt.Errorf("label %s is already defined at line %d", label, line))
+}
+
+// checkLabels reports a label at the end of the input that has no operation to label, and every branch to a label
+// that is never defined
+func (p *parser) checkLabels() {
+	if p.label != "" {
+		p.report(locate(p.labelAt, m.CodeDanglingLabel, fmt.Errorf("label %s is not followed by an operation", p.label)))
+		p.label = ""
+	}
+
+	for _, t := range p.targets {
+		if _, ok := p.labels[t.label]; !ok {
+			p.report(locate(t.at, m.CodeUndefinedLabel, fmt.Errorf("branch to undefined label %s", t.label)))
+		}
+	}
+}
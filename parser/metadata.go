@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+const SIMINPUTHEADER = "SIM INPUT:"
+const OUTPUTHEADER = "OUTPUT:"
+
+// recordComment pulls the //SIM INPUT and //OUTPUT headers out of a comment into the block's metadata
+func (p *parser) recordComment(text string) error {
+	text = strings.TrimSpace(text)
+
+	switch {
+	case strings.HasPrefix(text, SIMINPUTHEADER):
+		address, memory, err := m.ParseSimInput(strings.TrimPrefix(text, SIMINPUTHEADER))
+		if err != nil {
+			return fmt.Errorf("invalid //%s header: %w", SIMINPUTHEADER, err)
+		}
+
+		p.metadata.HasSimInput = true
+		p.metadata.MemoryAddress = address
+		p.metadata.Memory = memory
+	case strings.HasPrefix(text, OUTPUTHEADER):
+		outputs, err := m.ParseValues(strings.TrimPrefix(text, OUTPUTHEADER))
+		if err != nil {
+			return fmt.Errorf("invalid //%s header: %w", OUTPUTHEADER, err)
+		}
+
+		p.metadata.HasOutput = true
+		p.metadata.ExpectedOutputs = outputs
+	}
+
+	return nil
+}
+
+// GetMetadata returns the test information found in the block's header comments
+func (p *parser) GetMetadata() m.Metadata {
+	return p.metadata
+}
@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+	s "github.com/bivguy/Comp412/scanner"
+)
+
+// chunk is a part of the input made of whole lines, along with the number of its first line
+type chunk struct {
+	text []byte
+	line int
+}
+
+// NewParallel returns a parser that splits input into about the given number of chunks of whole lines, then scans and
+// parses the chunks on separate goroutines when Parse is called. ILOC operations never span lines, so merging the
+// chunks in order gives the same diagnostics as parsing the input in one piece, and for an input without errors, the
+// same IR and metadata.
+func NewParallel(input []byte, chunks int) *parser {
+	p := New(nil)
+	p.input = input
+	p.chunks = max(chunks, 1)
+
+	return p
+}
+
+// parseChunks parses each chunk of the input with a parser of its own, all at once, then merges them in order
+func (p *parser) parseChunks() (*ir.Block, []m.Diagnostic, error) {
+	chunks := splitLines(p.input, p.chunks)
+	parts := make([]*parser, len(chunks))
+
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		parts[i] = New(s.NewAt(bytes.NewReader(chunk.text), chunk.line))
+		parts[i].MaxErrors = p.MaxErrors
+
+		wg.Add(1)
+		go func(part *parser) {
+			defer wg.Done()
+			part.parseOperations()
+		}(parts[i])
+	}
+	wg.Wait()
+
+	for _, part := range parts {
+		p.merge(part)
+	}
+
+	return ir.New(p.operations...), p.diagnostics, p.finish()
+}
+
+// merge adds what the parser of the next chunk found to p, as if p had gone on to parse the chunk itself
+func (p *parser) merge(part *parser) {
+	if p.stopped() {
+		return
+	}
+
+	p.operations = append(p.operations, part.operations...)
+	p.largestRegister = max(p.largestRegister, part.largestRegister)
+
+	if part.metadata.HasSimInput {
+		p.metadata.HasSimInput = true
+		p.metadata.MemoryAddress = part.metadata.MemoryAddress
+		p.metadata.Memory = part.metadata.Memory
+	}
+	if part.metadata.HasOutput {
+		p.metadata.HasOutput = true
+		p.metadata.ExpectedOutputs = part.metadata.ExpectedOutputs
+	}
+
+	// the part could not see the labels of earlier chunks, so a label it defined again is reported here, in line order
+	// among the part's own diagnostics
+	diagnostics := part.diagnostics
+	for _, d := range part.definitions {
+		if line, ok := p.labels[d.label]; ok {
+			diagnostics = insertByLine(diagnostics, duplicateLabel(d.label, d.at, line))
+			continue
+		}
+		p.labels[d.label] = d.at.Line
+		p.definitions = append(p.definitions, d)
+	}
+
+	for _, d := range diagnostics {
+		if p.stopped() {
+			return
+		}
+		p.report(d)
+	}
+
+	p.targets = append(p.targets, part.targets...)
+	p.label, p.labelAt = part.label, part.labelAt
+}
+
+// insertByLine inserts d before the first diagnostic at its line or later
+func insertByLine(diagnostics []m.Diagnostic, d m.Diagnostic) []m.Diagnostic {
+	i := 0
+	for i < len(diagnostics) && diagnostics[i].Line < d.Line {
+		i++
+	}
+
+	diagnostics = append(diagnostics[:i:i], append([]m.Diagnostic{d}, diagnostics[i:]...)...)
+	return diagnostics
+}
+
+// splitLines splits input into about n chunks of whole lines. A chunk only ends after a line with an operation on it,
+// so a label or the comments before an operation always land in the same chunk as the operation.
+func splitLines(input []byte, n int) []chunk {
+	var chunks []chunk
+	size := len(input)/n + 1
+
+	start, line := 0, 1
+	for start < len(input) {
+		end := min(start+size, len(input))
+		for end < len(input) {
+			newline := bytes.IndexByte(input[end:], '\n')
+			if newline < 0 {
+				end = len(input)
+				break
+			}
+			end += newline + 1
+
+			if endsOperation(lastLine(input[start:end])) {
+				break
+			}
+		}
+
+		chunks = append(chunks, chunk{text: input[start:end], line: line})
+		line += bytes.Count(input[start:end], []byte{'\n'})
+		start = end
+	}
+
+	return chunks
+}
+
+// lastLine returns the last line of text, which ends with a newline
+func lastLine(text []byte) []byte {
+	text = bytes.TrimSuffix(text, []byte{'\n'})
+
+	return text[bytes.LastIndexByte(text, '\n')+1:]
+}
+
+// endsOperation reports whether a line has an operation on it: something other than a comment that is not a label
+// waiting for the operation on a later line
+func endsOperation(line []byte) bool {
+	if comment := bytes.Index(line, []byte("//")); comment >= 0 {
+		line = line[:comment]
+	}
+	line = bytes.TrimSpace(line)
+
+	return len(line) > 0 && line[len(line)-1] != ':'
+}
@@ -1,84 +1,173 @@
 package parser
 
 import (
-	"container/list"
+	"errors"
 	"fmt"
-	"os"
 
 	c "github.com/bivguy/Comp412/constants"
+	"github.com/bivguy/Comp412/ir"
 	"github.com/bivguy/Comp412/models"
 	m "github.com/bivguy/Comp412/models"
 )
 
+// ErrParse is returned by Parse and ParseSchedule when the input has errors; the errors themselves are in the diagnostics
+var ErrParse = errors.New("parse found errors")
+
+// ErrTooManyErrors is returned instead of ErrParse when the parser stopped early because it reached MaxErrors
+var ErrTooManyErrors = fmt.Errorf("%w: stopped at the error limit", ErrParse)
+
 type parser struct {
 	scanner          scanner
 	currentOperation m.OperationNode
-	operations       *list.List
+	operations       []*m.OperationNode
 	largestRegister  int
+	metadata         m.Metadata
+	diagnostics      []m.Diagnostic
+	errors           int
 	ErrorFound       bool
+	MaxErrors        int // the parser stops once it has found this many errors; 0 means no limit
+
+	// the label waiting for the next operation and where it was defined, the line on which each label was defined,
+	// where each label was defined in order, and every label a branch goes to
+	label       string
+	labelAt     m.Diagnostic
+	labels      map[string]int
+	definitions []target
+	targets     []target
+
+	// the comments waiting to be attached to the next operation
+	comments []string
+
+	// the whole input and the number of chunks to split it into, for a parser made by NewParallel
+	input  []byte
+	chunks int
+
+	// set while parsing a schedule, where an operation ends at a ';' or ']' instead of at the end of the line
+	bundles    bool
+	terminator m.Token
 }
 
 type scanner interface {
 	NextToken() (models.Token, error)
 	SetNextLine()
 	GetCurrentLine() int
+	CommentText() string
+	Source() string
+	Columns() (int, int)
 }
 
 func New(scanner scanner) *parser {
-	return &parser{scanner: scanner, operations: list.New()}
+	return &parser{scanner: scanner, labels: make(map[string]int)}
+}
+
+// Parse builds the IR of the input. Every problem found is returned as a diagnostic, and parsing goes on after an
+// error until the end of the input or MaxErrors; if any diagnostic is an error, ErrorFound is set and Parse returns
+// ErrParse or ErrTooManyErrors.
+func (p *parser) Parse() (*ir.Block, []m.Diagnostic, error) {
+	if p.chunks > 0 {
+		return p.parseChunks()
+	}
+
+	p.parseOperations()
+	return ir.New(p.operations...), p.diagnostics, p.finish()
 }
 
-func (p *parser) Parse() (*list.List, error) {
-	token := p.nextOperationToken()
-
-	// calls the corresponding helper function to finish building its operation
-	for token.Category != c.EOF {
-		// once we get a valid lexeme, start building the internal representation
-		p.currentOperation.Line = token.LineNumber
-		p.currentOperation.Opcode = token.Lexeme
-		var err error
-		switch token.Category {
-		case c.MEMOP:
-			err = p.finishMemop()
-		case c.LOADI:
-			err = p.finishLoadI()
-		case c.ARITHOP:
-			err = p.finishArithop()
-		case c.OUTPUT:
-			err = p.finishOutput()
-		case c.NOP:
-			err = p.finishNOP()
-		default:
-			p.currentOperation = m.OperationNode{}
-			err = fmt.Errorf("expected a valid opcode category but instead got %v", token)
+// parseOperations parses every operation of the input into the IR, reporting the errors it finds on the way
+func (p *parser) parseOperations() {
+	for op := p.nextOperation(); op != nil; op = p.nextOperation() {
+		p.operations = append(p.operations, op)
+	}
+}
+
+// nextOperation parses up to the next operation without errors, reporting the errors it finds on the way. It returns
+// nil at the end of the input, or once the parser has stopped at MaxErrors.
+func (p *parser) nextOperation() *m.OperationNode {
+	for {
+		token := p.nextOperationToken()
+		if token.Category == c.EOF || p.stopped() {
+			return nil
 		}
 
-		if err != nil {
-			wrappedErr := fmt.Errorf("ERROR %d: %w", p.scanner.GetCurrentLine(), err)
-			fmt.Fprintln(os.Stderr, wrappedErr)
-			p.ErrorFound = true
-		} else {
-			op := p.currentOperation
-			p.operations.PushBack(&op)
+		if token.Category == c.LABEL {
+			if err := p.defineLabel(token); err != nil {
+				p.report(p.diagnose(m.CodeUnexpectedToken, err))
+				if p.stopped() {
+					return nil
+				}
+			}
+			continue
 		}
 
+		// calls the corresponding helper function to finish building its operation
+		err := p.finishOperation(token)
+		op := p.currentOperation
 		p.currentOperation = m.OperationNode{}
 
-		token = p.nextOperationToken()
+		if err != nil {
+			p.report(p.diagnose(m.CodeUnexpectedToken, err))
+			if p.stopped() {
+				return nil
+			}
+			continue
+		}
+
+		return &op
+	}
+}
+
+// builds the current operation starting from its opcode token
+func (p *parser) finishOperation(token m.Token) error {
+	// once we get a valid lexeme, start building the internal representation
+	p.currentOperation.Line = token.LineNumber
+	p.currentOperation.Source = p.scanner.Source()
+	p.currentOperation.Opcode = token.Lexeme
+	p.currentOperation.Label = p.label
+	p.label = ""
+	p.currentOperation.Comments = p.comments
+	p.comments = nil
+
+	switch token.Category {
+	case c.MEMOP:
+		return p.finishMemop()
+	case c.LOADI:
+		return p.finishLoadI()
+	case c.ARITHOP:
+		return p.finishArithop()
+	case c.IMMOP:
+		return p.finishImmop()
+	case c.STOREAI:
+		return p.finishStoreAI()
+	case c.STOREAO:
+		return p.finishStoreAO()
+	case c.OUTPUT:
+		return p.finishOutput()
+	case c.NOP:
+		return p.finishNOP()
+	case c.JUMPI:
+		return p.finishJumpI()
+	case c.JUMP:
+		return p.finishJump()
+	case c.CBR:
+		return p.finishCBR()
 	}
 
-	return p.operations, nil
+	p.currentOperation = m.OperationNode{}
+	return fmt.Errorf("expected a valid opcode category but instead got %v", token)
 }
 
 // helper function that gets the next token from the scanner that did not return any errors
 func (p *parser) nextCorrectToken() m.Token {
 	token, err := p.scanner.NextToken()
 
-	// keep printing out the errors of the scanner if they occur
+	// keep recording the errors of the scanner if they occur, unless the parser has already stopped
 	for err != nil {
-		p.ErrorFound = true
-		wrappedErr := fmt.Errorf("ERROR %d: %w", p.scanner.GetCurrentLine(), err)
-		fmt.Fprintln(os.Stderr, wrappedErr)
+		if p.stopped() {
+			return m.Token{Category: c.EOF, LineNumber: token.LineNumber}
+		}
+		p.report(p.diagnose(m.CodeInvalidToken, err))
+		if p.stopped() {
+			return m.Token{Category: c.EOF, LineNumber: token.LineNumber}
+		}
 		token, err = p.scanner.NextToken()
 	}
 
@@ -90,12 +179,29 @@ func (p *parser) nextOperationToken() m.Token {
 	token := p.nextCorrectToken()
 
 	for token.Category == c.EOL || token.Category == c.COMMENT {
+		if token.Category == c.COMMENT {
+			p.comment(&p.comments)
+		}
 		token = p.nextCorrectToken()
 	}
 
 	return token
 }
 
+// comment keeps the comment the scanner just returned with the comments given, those of the next operation or of the
+// one it ends, and records the metadata in it, reporting a malformed header like any other error
+func (p *parser) comment(comments *[]string) {
+	*comments = append(*comments, p.scanner.CommentText())
+
+	err := p.recordComment(p.scanner.CommentText())
+	if err != nil {
+		// point at the whole comment rather than just its '//'
+		d := p.diagnose(m.CodeInvalidHeader, err)
+		d.Span = len(d.Source) - d.Column + 1
+		p.report(d)
+	}
+}
+
 func (p *parser) GetLargestRegister() int {
 	return p.largestRegister
 }
@@ -17,6 +17,7 @@ const (
 	OPTWO
 	OPTHREE
 	SKIP
+	TARGET // a label the branch may go to
 )
 
 var memopCategories = []m.SyntacticCategory{c.REGISTER, c.INTO, c.REGISTER, c.EO}
@@ -28,9 +29,26 @@ var loadIArgs = []category{OPONE, SKIP, OPTHREE, SKIP}
 var arithopCategories = []m.SyntacticCategory{c.REGISTER, c.COMMA, c.REGISTER, c.INTO, c.REGISTER, c.EO}
 var arithipArgs = []category{OPONE, SKIP, OPTWO, SKIP, OPTHREE, SKIP}
 
+var immopCategories = []m.SyntacticCategory{c.REGISTER, c.COMMA, c.CONSTANT, c.INTO, c.REGISTER, c.EO}
+var immopArgs = []category{OPONE, SKIP, OPTWO, SKIP, OPTHREE, SKIP}
+
+// storeAI and storeAO keep the base address register in OpThree, like store, and the offset in OpTwo
+var storeAICategories = []m.SyntacticCategory{c.REGISTER, c.INTO, c.REGISTER, c.COMMA, c.CONSTANT, c.EO}
+var storeAOCategories = []m.SyntacticCategory{c.REGISTER, c.INTO, c.REGISTER, c.COMMA, c.REGISTER, c.EO}
+var storeArgs = []category{OPONE, SKIP, OPTHREE, SKIP, OPTWO, SKIP}
+
 var outputCategories = []m.SyntacticCategory{c.CONSTANT, c.EO}
 var outputArgs = []category{OPTHREE, SKIP}
 
+var jumpICategories = []m.SyntacticCategory{c.ARROW, c.LABEL, c.EO}
+var jumpIArgs = []category{SKIP, TARGET, SKIP}
+
+var jumpCategories = []m.SyntacticCategory{c.ARROW, c.REGISTER, c.EO}
+var jumpArgs = []category{SKIP, OPONE, SKIP}
+
+var cbrCategories = []m.SyntacticCategory{c.REGISTER, c.ARROW, c.LABEL, c.COMMA, c.LABEL, c.EO}
+var cbrArgs = []category{OPONE, SKIP, TARGET, SKIP, TARGET, SKIP}
+
 var nopCategories = []m.SyntacticCategory{c.EO}
 var nopArgs = []category{SKIP}
 
@@ -61,6 +79,33 @@ func (p *parser) finishArithop() error {
 	return nil
 }
 
+func (p *parser) finishImmop() error {
+	err := p.buildCategories(immopCategories, immopArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) finishStoreAI() error {
+	err := p.buildCategories(storeAICategories, storeArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) finishStoreAO() error {
+	err := p.buildCategories(storeAOCategories, storeArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (p *parser) finishOutput() error {
 	err := p.buildCategories(outputCategories, outputArgs)
 	if err != nil {
@@ -70,6 +115,33 @@ func (p *parser) finishOutput() error {
 	return nil
 }
 
+func (p *parser) finishJumpI() error {
+	err := p.buildCategories(jumpICategories, jumpIArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) finishJump() error {
+	err := p.buildCategories(jumpCategories, jumpArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *parser) finishCBR() error {
+	err := p.buildCategories(cbrCategories, cbrArgs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (p *parser) finishNOP() error {
 	err := p.buildCategories(nopCategories, nopArgs)
 	if err != nil {
@@ -88,12 +160,22 @@ func (p *parser) buildCategories(expectedCategories []m.SyntacticCategory, expec
 		}
 		tokenCat := token.Category
 		// check the special case of EO
-		if cat == c.EO {
+		if cat == c.EO && p.bundles {
+			if tokenCat != c.SEMICOLON && tokenCat != c.RBRACKET {
+				p.scanner.SetNextLine()
+				return fmt.Errorf("encountered an error at line %d: expected a token of type SEMICOLON or RBRACKET but got one of type %v", token.LineNumber, c.SyntacticCategories[tokenCat])
+			}
+			p.terminator = token
+		} else if cat == c.EO {
 			if tokenCat != c.EOL && tokenCat != c.EOF && tokenCat != c.COMMENT {
 				// this makes a new line in the scanner because a parser error does not start a new line in the scanner
 				p.scanner.SetNextLine()
 				return fmt.Errorf("encountered an error at line %d: expected a token of type EOF or EOF but got one of type %v", token.LineNumber, c.SyntacticCategories[tokenCat])
 			}
+			// a comment at the end of the line belongs to the operation on it
+			if tokenCat == c.COMMENT {
+				p.comment(&p.currentOperation.Comments)
+			}
 		} else if tokenCat != cat {
 			p.scanner.SetNextLine()
 			return parserError(token.Category, cat, token)
@@ -116,11 +198,17 @@ func (p *parser) buildOperation(token m.Token, arg category) error {
 		return nil
 	}
 
+	if arg == TARGET {
+		p.currentOperation.Targets = append(p.currentOperation.Targets, token.Lexeme)
+		p.targets = append(p.targets, target{label: token.Lexeme, at: p.position()})
+		return nil
+	}
+
 	lexeme := token.Lexeme
 	SR, err := p.sourceRegisterHelper(lexeme)
 
 	if err != nil {
-		return err
+		return codedError{code: m.CodeInvalidOperand, err: err}
 	}
 
 	var op *m.Operand
@@ -143,7 +231,10 @@ func (p *parser) buildOperation(token m.Token, arg category) error {
 	}
 
 	op.SR = SR
+	op.StartColumn = token.StartColumn
+	op.EndColumn = token.EndColumn
 	op.VR = -1
+	op.PR = -1
 	op.NU = math.Inf(1)
 
 	return nil
@@ -164,11 +255,3 @@ func (p *parser) sourceRegisterHelper(lexeme string) (int, error) {
 
 	return SR, nil
 }
-
-func isRegister(opcode string, cat category) bool {
-	if (opcode == "loadI" && cat == OPONE) || (opcode == "output" && cat == OPTHREE) {
-		return false
-	}
-
-	return true
-}
@@ -1,11 +1,16 @@
 package parser
 
 import (
-	"container/list"
+	"bytes"
+	"errors"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
 	s "github.com/bivguy/Comp412/scanner"
 )
 
@@ -31,7 +36,7 @@ func TestParserPerformance(t *testing.T) {
 			scanner := s.New(file)
 			parser := New(scanner)
 
-			_, err = parser.Parse()
+			_, _, err = parser.Parse()
 			if err != nil {
 				t.Errorf("Unexpected error: %v", err)
 			}
@@ -54,7 +59,7 @@ func runTest(tc TestCase, t *testing.T) {
 	defer file.Close()
 	scanner := s.New(file)
 	parser := New(scanner)
-	IR, err := parser.Parse()
+	IR, _, err := parser.Parse()
 	if err != nil {
 		if !tc.expectedError {
 			t.Errorf("Unexpected error: %v", err)
@@ -66,21 +71,328 @@ func runTest(tc TestCase, t *testing.T) {
 	}
 }
 
-func compareIR(expected, actual *list.List) bool {
+func compareIR(expected, actual *ir.Block) bool {
 	if expected.Len() != actual.Len() {
 		return false
 	}
 
-	e1 := expected.Front()
-	e2 := actual.Front()
-
-	for e1 != nil && e2 != nil {
-		if e1.Value != e2.Value {
+	for i, op := range expected.Ops() {
+		if !reflect.DeepEqual(*op, *actual.At(i)) {
 			return false
 		}
-		e1 = e1.Next()
-		e2 = e2.Next()
 	}
 
 	return true
 }
+
+func TestMetadata(t *testing.T) {
+	for _, tc := range metadataTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			file, err := os.Open(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer file.Close()
+
+			parser := New(s.New(file))
+			if _, _, err := parser.Parse(); err != nil || parser.ErrorFound {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, parser.GetMetadata()) {
+				t.Errorf("expected metadata %+v but got %+v", tc.expected, parser.GetMetadata())
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, tc := range scheduleTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			file, err := os.Open(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer file.Close()
+
+			parser := New(s.New(file))
+			schedule, _, err := parser.ParseSchedule()
+			if errors.Is(err, ErrParse) != tc.expectedError || parser.ErrorFound != tc.expectedError {
+				t.Fatalf("Unexpected error: %v (error found: %t)", err, parser.ErrorFound)
+			}
+
+			var actual [][]string
+			for _, bundle := range schedule {
+				var ops []string
+				for _, op := range bundle {
+					ops = append(ops, op.Opcode)
+				}
+				actual = append(actual, ops)
+			}
+
+			if !reflect.DeepEqual(tc.expectedOpcodes, actual) {
+				t.Errorf("expected bundles %v but got %v", tc.expectedOpcodes, actual)
+			}
+		})
+	}
+}
+
+func TestOperandShapes(t *testing.T) {
+	for _, tc := range operandTestCases {
+		t.Run(tc.input, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input + "\n")))
+			IR, _, err := parser.Parse()
+			if err != nil || parser.ErrorFound || IR.Len() != 1 {
+				t.Fatalf("Unexpected error: %v (error found: %t)", err, parser.ErrorFound)
+			}
+
+			op := IR.At(0)
+			actual := [3]int{-1, -1, -1}
+			for i, o := range []m.Operand{op.OpOne, op.OpTwo, op.OpThree} {
+				if o.Active {
+					actual[i] = o.SR
+				}
+			}
+
+			if op.Opcode != tc.expectedOpcode || actual != tc.expectedSRs {
+				t.Errorf("expected %s %v but got %s %v", tc.expectedOpcode, tc.expectedSRs, op.Opcode, actual)
+			}
+
+			if tc.expectedColumns != ([3][2]int{}) {
+				var columns [3][2]int
+				for i, o := range []m.Operand{op.OpOne, op.OpTwo, op.OpThree} {
+					columns[i] = [2]int{o.StartColumn, o.EndColumn}
+				}
+				if columns != tc.expectedColumns {
+					t.Errorf("expected operand columns %v but got %v", tc.expectedColumns, columns)
+				}
+			}
+		})
+	}
+}
+
+func TestLabels(t *testing.T) {
+	for _, tc := range labelTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input)))
+			IR, _, err := parser.Parse()
+			if errors.Is(err, ErrParse) != tc.expectedError || parser.ErrorFound != tc.expectedError {
+				t.Fatalf("Unexpected error: %v (error found: %t)", err, parser.ErrorFound)
+			}
+			if tc.expectedError {
+				return
+			}
+
+			var labels []string
+			var targets [][]string
+			for _, op := range IR.Ops() {
+				labels = append(labels, op.Label)
+				targets = append(targets, op.Targets)
+			}
+
+			if !reflect.DeepEqual(tc.expectedLabels, labels) || !reflect.DeepEqual(tc.expectedTargets, targets) {
+				t.Errorf("expected labels %v and targets %v but got %v and %v", tc.expectedLabels, tc.expectedTargets, labels, targets)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	for _, tc := range diagnosticTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input)))
+			_, diagnostics, err := parser.Parse()
+			if errors.Is(err, ErrParse) != (len(tc.expected) > 0) || parser.ErrorFound != (len(tc.expected) > 0) {
+				t.Fatalf("Unexpected error: %v (error found: %t)", err, parser.ErrorFound)
+			}
+
+			var actual []m.Diagnostic
+			for _, d := range diagnostics {
+				actual = append(actual, m.Diagnostic{Line: d.Line, Column: d.Column, Span: d.Span, Code: d.Code})
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected diagnostics %+v but got %+v", tc.expected, actual)
+			}
+
+			if tc.rendered != "" && len(diagnostics) > 0 && diagnostics[0].Render() != tc.rendered {
+				t.Errorf("expected the first diagnostic to render as\n%s\nbut got\n%s", tc.rendered, diagnostics[0].Render())
+			}
+		})
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	for _, tc := range maxErrorsTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input)))
+			parser.MaxErrors = tc.maxErrors
+			_, diagnostics, err := parser.Parse()
+			if err != tc.expectedErr {
+				t.Errorf("expected %v but got %v", tc.expectedErr, err)
+			}
+
+			var lines []int
+			for _, d := range diagnostics {
+				lines = append(lines, d.Line)
+			}
+			if !reflect.DeepEqual(tc.expectedLines, lines) {
+				t.Errorf("expected diagnostics at lines %v but got %v", tc.expectedLines, lines)
+			}
+		})
+	}
+}
+
+func TestComments(t *testing.T) {
+	for _, tc := range commentTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input)))
+
+			var ops []*m.OperationNode
+			var err error
+			if tc.bundles {
+				var schedule [][]*m.OperationNode
+				schedule, _, err = parser.ParseSchedule()
+				for _, bundle := range schedule {
+					ops = append(ops, bundle...)
+				}
+			} else {
+				var IR *ir.Block
+				IR, _, err = parser.Parse()
+				ops = IR.Ops()
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var comments [][]string
+			for _, op := range ops {
+				comments = append(comments, op.Comments)
+			}
+			if !reflect.DeepEqual(tc.expectedComments, comments) {
+				t.Errorf("expected comments %q but got %q", tc.expectedComments, comments)
+			}
+
+			if metadata := parser.GetMetadata(); tc.expectedOutputs != nil && !reflect.DeepEqual(tc.expectedOutputs, metadata.ExpectedOutputs) {
+				t.Errorf("expected the outputs %v from the header but got %v", tc.expectedOutputs, metadata.ExpectedOutputs)
+			}
+		})
+	}
+}
+
+func TestParseParallel(t *testing.T) {
+	for _, tc := range parallelTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			input := []byte(tc.text)
+			if tc.input != "" {
+				var err error
+				if input, err = os.ReadFile(tc.input); err != nil {
+					t.Fatalf("Failed to read file: %v", err)
+				}
+			}
+
+			sequential := New(s.New(bytes.NewReader(input)))
+			sequential.MaxErrors = tc.maxErrors
+			expectedIR, expectedDiagnostics, expectedErr := sequential.Parse()
+
+			for _, chunks := range []int{1, 2, 3, 7, 16} {
+				parallel := NewParallel(input, chunks)
+				parallel.MaxErrors = tc.maxErrors
+				IR, diagnostics, err := parallel.Parse()
+
+				if err != expectedErr {
+					t.Errorf("%d chunks: expected %v but got %v", chunks, expectedErr, err)
+				}
+				if !reflect.DeepEqual(expectedDiagnostics, diagnostics) {
+					t.Errorf("%d chunks: expected diagnostics %+v but got %+v", chunks, expectedDiagnostics, diagnostics)
+				}
+				// the IR is only complete when the input has no errors
+				if expectedErr != nil {
+					continue
+				}
+				if !reflect.DeepEqual(operations(expectedIR), operations(IR)) {
+					t.Errorf("%d chunks: the IR differs from parsing in one piece", chunks)
+				}
+				if !reflect.DeepEqual(sequential.GetMetadata(), parallel.GetMetadata()) || sequential.GetLargestRegister() != parallel.GetLargestRegister() {
+					t.Errorf("%d chunks: expected metadata %+v up to r%d but got %+v up to r%d", chunks,
+						sequential.GetMetadata(), sequential.GetLargestRegister(), parallel.GetMetadata(), parallel.GetLargestRegister())
+				}
+			}
+		})
+	}
+}
+
+func TestOps(t *testing.T) {
+	for _, tc := range opsTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := New(s.New(strings.NewReader(tc.input)))
+			parser.MaxErrors = tc.maxErrors
+
+			var actual []string
+			for op, err := range parser.Ops() {
+				var d m.Diagnostic
+				switch {
+				case op != nil:
+					actual = append(actual, op.Opcode)
+				case errors.As(err, &d):
+					actual = append(actual, d.Code)
+				case errors.Is(err, ErrTooManyErrors):
+					actual = append(actual, "stopped")
+				default:
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if len(actual) == tc.breakAfter {
+					break
+				}
+			}
+
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// TestOpsParse checks that streaming a file yields the same operations and diagnostics as parsing it in one piece, also
+// when the parser was made by NewParallel
+func TestOpsParse(t *testing.T) {
+	for _, path := range []string{"parser_tests/complex_tests/t128k.i.txt", "../test_files/t1.i.txt"} {
+		t.Run(path, func(t *testing.T) {
+			input, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read file: %v", err)
+			}
+			IR, expected, _ := New(s.New(bytes.NewReader(input))).Parse()
+
+			for _, parser := range []*parser{New(s.New(bytes.NewReader(input))), NewParallel(input, 4)} {
+				var ops []*m.OperationNode
+				var diagnostics []m.Diagnostic
+				for op, err := range parser.Ops() {
+					var d m.Diagnostic
+					if op != nil {
+						ops = append(ops, op)
+					} else if errors.As(err, &d) {
+						diagnostics = append(diagnostics, d)
+					}
+				}
+
+				// the operation IDs are given by ir.New
+				if !reflect.DeepEqual(operations(IR), operations(ir.New(ops...))) {
+					t.Errorf("expected %d operations but got %d that differ", IR.Len(), len(ops))
+				}
+				if !reflect.DeepEqual(expected, diagnostics) {
+					t.Errorf("expected diagnostics %+v but got %+v", expected, diagnostics)
+				}
+			}
+		})
+	}
+}
+
+// operations returns the operations of the IR in order
+func operations(IR *ir.Block) []m.OperationNode {
+	var ops []m.OperationNode
+	for _, op := range IR.Ops() {
+		ops = append(ops, *op)
+	}
+
+	return ops
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"errors"
+	"iter"
+
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Ops parses the input one operation at a time, yielding each operation as soon as it is built, so a consumer can
+// work through a block of any size while it is still being parsed. The operations are not kept, but the parser still
+// remembers every label, branch target and diagnostic it has seen, so its memory grows with those.
+//
+// Each problem in the input is yielded as a nil operation with its m.Diagnostic as the error, and parsing goes on
+// after it, the same way Parse does. Labels are checked once the whole input has been read, so an undefined label is
+// only yielded at the end. If the parser stops at MaxErrors, the last error yielded is ErrTooManyErrors. A parser made
+// by NewParallel parses the whole input first, then yields its operations and diagnostics in line order.
+func (p *parser) Ops() iter.Seq2[*m.OperationNode, error] {
+	return func(yield func(*m.OperationNode, error) bool) {
+		if p.chunks > 0 {
+			p.yieldParsed(yield)
+			return
+		}
+
+		// yieldDiagnostics yields the diagnostics reported since it was last called
+		reported := 0
+		yieldDiagnostics := func() bool {
+			for ; reported < len(p.diagnostics); reported++ {
+				if !yield(nil, p.diagnostics[reported]) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for op := p.nextOperation(); ; op = p.nextOperation() {
+			if !yieldDiagnostics() {
+				return
+			}
+			if op == nil {
+				break
+			}
+			if !yield(op, nil) {
+				return
+			}
+		}
+
+		err := p.finish()
+		if yieldDiagnostics() && errors.Is(err, ErrTooManyErrors) {
+			yield(nil, err)
+		}
+	}
+}
+
+// yieldParsed parses the whole input with Parse, then yields its operations and diagnostics in line order
+func (p *parser) yieldParsed(yield func(*m.OperationNode, error) bool) {
+	IR, diagnostics, err := p.Parse()
+
+	ops := IR.Ops()
+	for _, d := range diagnostics {
+		// yield the operations that come before the diagnostic's line first
+		for len(ops) > 0 && ops[0].Line < d.Line {
+			if !yield(ops[0], nil) {
+				return
+			}
+			ops = ops[1:]
+		}
+		if !yield(nil, d) {
+			return
+		}
+	}
+	for _, op := range ops {
+		if !yield(op, nil) {
+			return
+		}
+	}
+
+	if errors.Is(err, ErrTooManyErrors) {
+		yield(nil, err)
+	}
+}
@@ -0,0 +1,230 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bivguy/Comp412/allocator"
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+	"github.com/bivguy/Comp412/parser"
+	"github.com/bivguy/Comp412/renamer"
+	"github.com/bivguy/Comp412/scanner"
+	"github.com/bivguy/Comp412/scheduler"
+	"github.com/bivguy/Comp412/validate"
+)
+
+// ErrParse is returned by the parse pass when the block has errors; the errors themselves are in the program's
+// Diagnostics. When the pass stops at its error limit, the error is parser.ErrTooManyErrors, which wraps ErrParse.
+var ErrParse = parser.ErrParse
+
+// ErrInvalid is returned by a strict validate pass that found problems in the block; they are in the program's
+// Diagnostics
+var ErrInvalid = validate.ErrInvalid
+
+// ParseResult is the block as written, with the //SIM INPUT and //OUTPUT metadata from its comments
+type ParseResult struct {
+	IR              *ir.Block
+	Metadata        m.Metadata
+	LargestRegister int
+}
+
+// RenameResult is the block renamed to virtual registers, along with the maps the allocator needs
+type RenameResult struct {
+	IR           *ir.Block
+	SRToVR       []int
+	LU           []float64
+	MaxVR        int
+	VRToConstant map[int]int
+
+	// the live range of each value, the number of values live as each operation issues, and the largest such number;
+	// only set by RenameWithLiveRanges
+	Ranges   []renamer.LiveRange
+	Pressure []int
+	MaxLive  int
+}
+
+// AllocateResult is the block allocated to K physical registers, including any spill and restore code
+type AllocateResult struct {
+	IR *ir.Block
+	K  int
+}
+
+// ScheduleResult holds the operations issued in each cycle, one slot per functional unit of the program's machine
+type ScheduleResult struct {
+	Bundles [][]*m.OperationNode
+}
+
+type parsePass struct {
+	input     io.Reader
+	maxErrors int
+	chunks    int
+}
+
+// Parse scans and parses the block read from input
+func Parse(input io.Reader) Pass {
+	return &parsePass{input: input}
+}
+
+// ParseWithMaxErrors parses like Parse, but stops once maxErrors errors have been found; 0 means no limit
+func ParseWithMaxErrors(input io.Reader, maxErrors int) Pass {
+	return &parsePass{input: input, maxErrors: maxErrors}
+}
+
+// ParseParallel parses like ParseWithMaxErrors, but reads the whole input, splits it into about the given number of
+// chunks of whole lines and parses them on separate goroutines; see parser.NewParallel
+func ParseParallel(input io.Reader, chunks int, maxErrors int) Pass {
+	return &parsePass{input: input, maxErrors: maxErrors, chunks: chunks}
+}
+
+func (p *parsePass) Name() string {
+	return "parse"
+}
+
+func (p *parsePass) Run(program *Program) error {
+	blockParser := parser.New(scanner.New(p.input))
+	if p.chunks > 1 {
+		input, err := io.ReadAll(p.input)
+		if err != nil {
+			return fmt.Errorf("could not read the input: %w", err)
+		}
+		blockParser = parser.NewParallel(input, p.chunks)
+	}
+	blockParser.MaxErrors = p.maxErrors
+
+	IR, diagnostics, err := blockParser.Parse()
+	program.Diagnostics = append(program.Diagnostics, diagnostics...)
+	if err != nil {
+		return err
+	}
+
+	program.Parsed = &ParseResult{IR: IR, Metadata: blockParser.GetMetadata(), LargestRegister: blockParser.GetLargestRegister()}
+	return nil
+}
+
+type validatePass struct {
+	strict bool
+}
+
+// Validate checks the parsed block for registers read before they are written, loadI and output constants that do
+// not fit in 32 bits and very large register numbers. It reports them as warnings, or as errors that stop the program
+// if strict is set.
+func Validate(strict bool) Pass {
+	return &validatePass{strict: strict}
+}
+
+func (v *validatePass) Name() string {
+	return "validate"
+}
+
+func (v *validatePass) Run(program *Program) error {
+	if program.Parsed == nil {
+		return requires("parse")
+	}
+
+	validator := validate.New(program.Parsed.IR)
+	validator.Strict = v.strict
+
+	diagnostics, err := validator.Validate()
+	program.Diagnostics = append(program.Diagnostics, diagnostics...)
+	return err
+}
+
+type renamePass struct {
+	live bool
+}
+
+// Rename renames the parsed block to virtual registers. The parsed IR is left as it was.
+func Rename() Pass {
+	return &renamePass{}
+}
+
+// RenameWithLiveRanges renames like Rename, and also finds the live ranges and register pressure of the renamed block
+func RenameWithLiveRanges() Pass {
+	return &renamePass{live: true}
+}
+
+func (r *renamePass) Name() string {
+	return "rename"
+}
+
+func (r *renamePass) Run(program *Program) error {
+	if program.Parsed == nil {
+		return requires("parse")
+	}
+
+	renamer := renamer.New(program.Parsed.LargestRegister, program.Parsed.IR.Copy())
+	renamer.Live = r.live
+	IR := renamer.Rename()
+
+	program.Renamed = &RenameResult{
+		IR:           IR,
+		SRToVR:       renamer.SRToVR,
+		LU:           renamer.LU,
+		MaxVR:        renamer.MaxVR,
+		VRToConstant: renamer.VRToConstant,
+
+		Ranges:   renamer.Ranges,
+		Pressure: renamer.Pressure,
+		MaxLive:  renamer.MaxLive,
+	}
+	return nil
+}
+
+type allocatePass struct {
+	k int
+}
+
+// Allocate allocates the renamed block to k physical registers. The renamed IR is left as it was.
+func Allocate(k int) Pass {
+	return &allocatePass{k: k}
+}
+
+func (a *allocatePass) Name() string {
+	return "allocate"
+}
+
+func (a *allocatePass) Run(program *Program) error {
+	renamed := program.Renamed
+	if renamed == nil {
+		return requires("rename")
+	}
+
+	allocator, err := allocator.New(renamed.SRToVR, renamed.LU, renamed.IR.Copy(), renamed.MaxVR, a.k, renamed.VRToConstant)
+	if err != nil {
+		return err
+	}
+
+	program.Allocated = &AllocateResult{IR: allocator.Allocate(), K: a.k}
+	return nil
+}
+
+type schedulePass struct{}
+
+// Schedule schedules the renamed block for the program's machine
+func Schedule() Pass {
+	return &schedulePass{}
+}
+
+func (s *schedulePass) Name() string {
+	return "schedule"
+}
+
+func (s *schedulePass) Run(program *Program) error {
+	if program.Renamed == nil {
+		return requires("rename")
+	}
+
+	bundles, err := scheduler.NewSchedule(program.Renamed.IR, program.Machine).Bundles()
+	if err != nil {
+		return err
+	}
+
+	program.Scheduled = &ScheduleResult{Bundles: bundles}
+	return nil
+}
+
+// requires reports a pass that was run before the pass whose result it reads
+func requires(pass string) error {
+	return fmt.Errorf("the %s pass must run first", pass)
+}
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Pass is one step of the compiler. It reads the results of the passes before it from the program and records its own.
+type Pass interface {
+	Name() string
+	Run(program *Program) error
+}
+
+// Program holds the input block and the result of every pass that has run on it. A result is nil until its pass runs.
+type Program struct {
+	Machine *machine.Description
+
+	Parsed    *ParseResult
+	Renamed   *RenameResult
+	Allocated *AllocateResult
+	Scheduled *ScheduleResult
+
+	// every problem the passes found in the input, in the order they were found, including the errors that stopped a pass
+	Diagnostics []m.Diagnostic
+}
+
+// NewProgram creates an empty program scheduled for the given machine, or for the COMP 412 machine if it is nil
+func NewProgram(description *machine.Description) *Program {
+	if description == nil {
+		description = machine.Default()
+	}
+
+	return &Program{Machine: description}
+}
+
+// IR returns the IR produced by the last pass that changes it: the allocated, renamed or parsed block, in that order
+func (p *Program) IR() *ir.Block {
+	switch {
+	case p.Allocated != nil:
+		return p.Allocated.IR
+	case p.Renamed != nil:
+		return p.Renamed.IR
+	case p.Parsed != nil:
+		return p.Parsed.IR
+	}
+
+	return nil
+}
+
+// Manager runs a sequence of passes over a program, stopping at the first pass that fails
+type Manager struct {
+	passes []Pass
+
+	// AfterPass, if set, is called after each pass succeeds, so a caller can inspect or print the intermediate results
+	AfterPass func(pass Pass, program *Program)
+}
+
+func NewManager(passes ...Pass) *Manager {
+	return &Manager{passes: passes}
+}
+
+// Add appends passes to the end of the sequence
+func (mgr *Manager) Add(passes ...Pass) {
+	mgr.passes = append(mgr.passes, passes...)
+}
+
+// Passes returns the passes in the order they run
+func (mgr *Manager) Passes() []Pass {
+	return mgr.passes
+}
+
+// Run runs every pass in order on the program. The error names the pass that failed.
+func (mgr *Manager) Run(program *Program) error {
+	for _, pass := range mgr.passes {
+		if err := pass.Run(program); err != nil {
+			return fmt.Errorf("%s: %w", pass.Name(), err)
+		}
+
+		if mgr.AfterPass != nil {
+			mgr.AfterPass(pass, program)
+		}
+	}
+
+	return nil
+}
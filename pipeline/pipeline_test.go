@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bivguy/Comp412/allocator"
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
+	m "github.com/bivguy/Comp412/models"
+	"github.com/bivguy/Comp412/simulator"
+)
+
+type TestCase struct {
+	description string
+	input       string
+	k           int
+}
+
+var pipelineTestCases = []TestCase{
+	{
+		description: "report2",
+		input:       "../test_files/report2.i",
+		k:           3,
+	},
+	{
+		description: "report16 with memory",
+		input:       "../test_files/report16.i",
+		k:           4,
+	},
+	{
+		description: "full ILOC opcode set",
+		input:       "../test_files/full_iloc.i",
+		k:           4,
+	},
+}
+
+// TestPipeline runs every pass and checks that the IR kept after each one still computes the block's //OUTPUT
+func TestPipeline(t *testing.T) {
+	for _, tc := range pipelineTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			file, err := os.Open(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer file.Close()
+
+			var names []string
+			manager := NewManager(Parse(file), Rename(), Allocate(tc.k), Schedule())
+			manager.AfterPass = func(pass Pass, program *Program) {
+				names = append(names, pass.Name())
+			}
+
+			program := NewProgram(nil)
+			if err := manager.Run(program); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if expected := []string{"parse", "rename", "allocate", "schedule"}; !reflect.DeepEqual(expected, names) {
+				t.Errorf("expected passes %v to run but got %v", expected, names)
+			}
+			if program.IR() != program.Allocated.IR {
+				t.Errorf("expected IR() to return the allocated block")
+			}
+			if len(program.Scheduled.Bundles) == 0 {
+				t.Errorf("expected a schedule but got no bundles")
+			}
+
+			metadata := program.Parsed.Metadata
+			stages := []struct {
+				name        string
+				IR          *ir.Block
+				registerSet simulator.RegisterSet
+			}{
+				{"parsed", program.Parsed.IR, simulator.SOURCE},
+				{"renamed", program.Renamed.IR, simulator.VIRTUAL},
+				{"allocated", program.Allocated.IR, simulator.PHYSICAL},
+			}
+
+			for _, stage := range stages {
+				sim := simulator.New(stage.registerSet, nil)
+				if err := sim.SetMemory(metadata.MemoryAddress, metadata.Memory); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if err := sim.Run(stage.IR); err != nil {
+					t.Fatalf("%s block: unexpected error: %v", stage.name, err)
+				}
+				if !reflect.DeepEqual(metadata.ExpectedOutputs, sim.Outputs()) {
+					t.Errorf("%s block: expected outputs %v but got %v", stage.name, metadata.ExpectedOutputs, sim.Outputs())
+				}
+			}
+		})
+	}
+}
+
+func TestPassOrder(t *testing.T) {
+	program := NewProgram(nil)
+
+	if err := NewManager(Allocate(5)).Run(program); err == nil {
+		t.Errorf("expected an error when allocating before renaming")
+	}
+	if program.IR() != nil {
+		t.Errorf("expected no IR but got %v", program.IR())
+	}
+
+	// the allocator is local, so a program with more than one block is renamed and scheduled but not allocated
+	file, err := os.Open("../test_files/branches.i")
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	if err := NewManager(Parse(file), Rename(), Schedule()).Run(program); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := Allocate(5).Run(program); !errors.Is(err, allocator.ErrMultipleBlocks) {
+		t.Errorf("expected ErrMultipleBlocks when allocating a program with branches but got %v", err)
+	}
+}
+
+// TestUnschedulable schedules a block on a machine with no unit for its load, which must fail instead of looping
+func TestUnschedulable(t *testing.T) {
+	description := &machine.Description{
+		Name:       "no memory",
+		IssueWidth: 1,
+		Units:      []machine.Unit{{Name: "f0", Classes: []string{"multiply"}}},
+		Opcodes:    map[string]machine.Opcode{"load": {Latency: 6, Class: "memory"}},
+	}
+
+	program := NewProgram(description)
+	input := "loadI 4 => r1\nload r1 => r2\n"
+	if err := NewManager(Parse(strings.NewReader(input)), Rename(), Schedule()).Run(program); err == nil {
+		t.Errorf("expected an error when no unit can issue a load")
+	}
+	if program.Scheduled != nil {
+		t.Errorf("expected no schedule but got %v", program.Scheduled.Bundles)
+	}
+}
+
+func TestParseString(t *testing.T) {
+	program := NewProgram(nil)
+	input := "loadI 4 => r1\nloadI 8 => r2\nadd r1,r2 => r3\n"
+
+	if err := NewManager(Parse(strings.NewReader(input)), Rename()).Run(program); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if program.Parsed.IR.Len() != 3 || program.Parsed.LargestRegister != 3 {
+		t.Errorf("expected 3 operations using up to r3 but got %d up to r%d", program.Parsed.IR.Len(), program.Parsed.LargestRegister)
+	}
+
+	parallel := NewProgram(nil)
+	if err := ParseParallel(strings.NewReader(input), 2, 0).Run(parallel); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if parallel.Parsed.IR.Len() != 3 || parallel.Parsed.LargestRegister != 3 {
+		t.Errorf("expected 3 operations using up to r3 in chunks but got %d up to r%d", parallel.Parsed.IR.Len(), parallel.Parsed.LargestRegister)
+	}
+
+	if err := Parse(strings.NewReader("loadI => r1\n")).Run(program); err != ErrParse {
+		t.Errorf("expected %v but got %v", ErrParse, err)
+	}
+	if len(program.Diagnostics) != 1 || program.Diagnostics[0].Line != 1 || program.Diagnostics[0].Code != m.CodeUnexpectedToken {
+		t.Errorf("expected one %s diagnostic at line 1 but got %v", m.CodeUnexpectedToken, program.Diagnostics)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	input := "loadI 4 => r1\nadd r1, r2 => r3\n"
+
+	program := NewProgram(nil)
+	if err := NewManager(Parse(strings.NewReader(input)), Validate(false), Rename()).Run(program); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(program.Diagnostics) != 1 || program.Diagnostics[0].Severity != m.WARNING || program.Diagnostics[0].Code != m.CodeUndefinedRead {
+		t.Errorf("expected one %s warning but got %v", m.CodeUndefinedRead, program.Diagnostics)
+	}
+
+	strict := NewProgram(nil)
+	if err := NewManager(Parse(strings.NewReader(input)), Validate(true), Rename()).Run(strict); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected %v but got %v", ErrInvalid, err)
+	}
+	if strict.Renamed != nil || len(strict.Diagnostics) != 1 || strict.Diagnostics[0].Severity != m.ERROR {
+		t.Errorf("expected the strict pass to stop the program with one error but got %v", strict.Diagnostics)
+	}
+}
+
+// BenchmarkPasses runs the parse, rename and allocate passes over the 128k operations of t128k.i.txt
+func BenchmarkPasses(b *testing.B) {
+	input, err := os.ReadFile("../test_files/t128k.i.txt")
+	if err != nil {
+		b.Fatalf("Failed to read file: %v", err)
+	}
+
+	program := NewProgram(nil)
+	passes := []struct {
+		name string
+		pass func() Pass
+	}{
+		{"parse", func() Pass { return Parse(bytes.NewReader(input)) }},
+		{"rename", Rename},
+		{"allocate", func() Pass { return Allocate(5) }},
+	}
+	for _, p := range passes {
+		b.Run(p.name, func(b *testing.B) {
+			for range b.N {
+				if err := p.pass().Run(program); err != nil {
+					b.Fatalf("Unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPipeline runs every pass from parsing to scheduling over t128k.i.txt in one manager, so a slowdown in any
+// pass or in the handoff between them shows up
+func BenchmarkPipeline(b *testing.B) {
+	input, err := os.ReadFile("../test_files/t128k.i.txt")
+	if err != nil {
+		b.Fatalf("Failed to read file: %v", err)
+	}
+
+	for range b.N {
+		program := NewProgram(nil)
+		if err := NewManager(Parse(bytes.NewReader(input)), Rename(), Allocate(5), Schedule()).Run(program); err != nil {
+			b.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
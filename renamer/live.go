@@ -0,0 +1,112 @@
+package renamer
+
+import (
+	"slices"
+
+	"github.com/bivguy/Comp412/cfg"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// LiveRange is the stretch of one basic block over which a virtual register holds one value: from the operation that
+// defines it to the last operation that reads it, by their positions in the renamed program. A value live on entry to
+// its block has LiveIn set and Def just before the block's first operation, so it is -1 in the first block. A value
+// live on exit from its block stays live to the block's last operation, and LastUse is -1 for a value that is never
+// read. A value that crosses blocks has a range in each block it is live in.
+type LiveRange struct {
+	VR      int
+	Def     int
+	LastUse int
+	LiveIn  bool
+}
+
+// Length is the number of operations the value is live across, counting its last use but not its definition
+func (lr LiveRange) Length() int {
+	if lr.LastUse < 0 {
+		return 0
+	}
+
+	return lr.LastUse - lr.Def
+}
+
+// trackLive walks the renamed operation at position i into the live ranges, going backwards like Rename: a definition
+// ends the range of the value it writes, and a read starts one if the value was not live yet. The pressure at the
+// operation is then the number of values live as it issues, including the ones it reads. Each block starts with the
+// values live on exit from it, from the liveness of the whole program, so a value carried around a loop is live up
+// to the branch back.
+func (r *renamer) trackLive(i int, op *m.OperationNode) {
+	if !r.Live {
+		return
+	}
+
+	operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+	operands := m.Opcodes[op.Opcode].Operands
+
+	for j, o := range operandList {
+		if !o.Active || operands[j] != m.DEF {
+			continue
+		}
+
+		lastUse, live := r.lastUse[o.VR]
+		if !live {
+			lastUse = -1
+		}
+		r.Ranges = append(r.Ranges, LiveRange{VR: o.VR, Def: i, LastUse: lastUse})
+		delete(r.lastUse, o.VR)
+	}
+
+	for j, o := range operandList {
+		if !o.Active || operands[j] != m.USE {
+			continue
+		}
+
+		if _, live := r.lastUse[o.VR]; !live {
+			r.lastUse[o.VR] = i
+		}
+	}
+
+	r.Pressure[i] = len(r.lastUse)
+	r.MaxLive = max(r.MaxLive, r.Pressure[i])
+}
+
+// liveOut starts the ranges of the values live on exit from the block whose last operation is at position i, before
+// the block is walked
+func (r *renamer) liveOut(i int, block *cfg.Block) {
+	if !r.Live {
+		return
+	}
+
+	for sr := range r.webs.out[block.ID] {
+		if _, live := r.lastUse[r.SRToVR[sr]]; !live {
+			r.lastUse[r.SRToVR[sr]] = i
+		}
+	}
+}
+
+// liveIn ends the ranges of the values still live once the first operation of a block, at position i, has been
+// walked; they are live on entry to the block
+func (r *renamer) liveIn(i int) {
+	if !r.Live {
+		return
+	}
+
+	for vr, lastUse := range r.lastUse {
+		r.Ranges = append(r.Ranges, LiveRange{VR: vr, Def: i - 1, LastUse: lastUse, LiveIn: true})
+	}
+	clear(r.lastUse)
+}
+
+// finishLive puts the ranges in the order of their definitions
+func (r *renamer) finishLive() {
+	if !r.Live {
+		return
+	}
+
+	r.lastUse = nil
+
+	slices.SortFunc(r.Ranges, func(a, b LiveRange) int {
+		if a.Def != b.Def {
+			return a.Def - b.Def
+		}
+		return a.VR - b.VR
+	})
+}
@@ -0,0 +1,84 @@
+package renamer
+
+import (
+	"github.com/bivguy/Comp412/cfg"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// liveness holds, for each basic block by its ID, the source registers whose values are live on entry to the block
+// and on exit from it
+type liveness struct {
+	in  [][]bool
+	out [][]bool
+}
+
+// liveVariables solves the live-variable dataflow equations over the blocks of a program:
+//
+//	LiveOut(b) = the union of LiveIn(s) over every successor s of b
+//	LiveIn(b)  = UEVar(b) ∪ (LiveOut(b) − VarKill(b))
+//
+// where UEVar(b) holds the registers b reads before writing them and VarKill(b) the registers it writes. The blocks
+// are visited from last to first, which follows the flow of liveness, until nothing changes.
+func liveVariables(blocks []*cfg.Block, maxSR int) liveness {
+	live := liveness{in: make([][]bool, len(blocks)), out: make([][]bool, len(blocks))}
+	ueVar := make([][]bool, len(blocks))
+	varKill := make([][]bool, len(blocks))
+
+	for _, block := range blocks {
+		live.in[block.ID] = make([]bool, maxSR+1)
+		live.out[block.ID] = make([]bool, maxSR+1)
+		ueVar[block.ID], varKill[block.ID] = localSets(block, maxSR)
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for i := len(blocks) - 1; i >= 0; i-- {
+			block := blocks[i]
+			in, out := live.in[block.ID], live.out[block.ID]
+
+			for _, succ := range block.Succs {
+				for sr, l := range live.in[succ.ID] {
+					if l && !out[sr] {
+						out[sr] = true
+					}
+				}
+			}
+
+			// the sets only grow, so a register that becomes live marks a change
+			for sr := range in {
+				if !in[sr] && (ueVar[block.ID][sr] || (out[sr] && !varKill[block.ID][sr])) {
+					in[sr] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	return live
+}
+
+// localSets finds the registers a block reads before it writes them, and the registers it writes
+func localSets(block *cfg.Block, maxSR int) (ueVar []bool, varKill []bool) {
+	ueVar = make([]bool, maxSR+1)
+	varKill = make([]bool, maxSR+1)
+
+	for _, op := range block.Ops {
+		operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+		operands := m.Opcodes[op.Opcode].Operands
+
+		// an operation reads its operands before it writes its result
+		for i, o := range operandList {
+			if o.Active && operands[i] == m.USE && !varKill[o.SR] {
+				ueVar[o.SR] = true
+			}
+		}
+		for i, o := range operandList {
+			if o.Active && operands[i] == m.DEF {
+				varKill[o.SR] = true
+			}
+		}
+	}
+
+	return ueVar, varKill
+}
@@ -1,27 +1,49 @@
 package renamer
 
 import (
-	"container/list"
+	"maps"
 	"math"
+	"slices"
 
+	"github.com/bivguy/Comp412/cfg"
+	"github.com/bivguy/Comp412/ir"
 	m "github.com/bivguy/Comp412/models"
 )
 
 type renamer struct {
 	SRToVR       []int
 	LU           []float64
-	VRToConstant map[int]int // a map from a virtual register to its rematerialization location
+	VRToConstant map[int]int // a map from a virtual register defined by a loadI to its constant, so it can be rematerialized
 
-	MaxSR int
-	MaxVR int
-	index int
+	MaxSR  int
+	MaxVR  int
+	index  int
+	vrName int // the next VR to hand out
 
 	deletePrevNode bool
 
-	IR *list.List
+	live       liveness                        // the registers live on entry to and exit from each basic block
+	webs       *webs                           // the values that cross block boundaries, grouped into webs
+	webVR      map[int]int                     // the VR of each web, by its root node
+	shared     map[int]bool                    // the VRs of webs with more than one definition
+	blockStart map[*m.OperationNode]bool       // the first operation of each basic block
+	blockEnd   map[*m.OperationNode]*cfg.Block // the last operation of each basic block, and its block
+	block      *cfg.Block                      // the block being renamed
+	defsAbove  map[int]int                     // for each register live on entry to the block, its definitions above the operation being renamed
+
+	// Live makes Rename track the live ranges and register pressure. Ranges are then the live ranges of the renamed
+	// block in the order of their definitions, and Pressure is the number of values live as each operation issues;
+	// MaxLive is the largest. See trackLive.
+	Live     bool
+	Ranges   []LiveRange
+	Pressure []int
+	MaxLive  int
+	lastUse  map[int]int // the position of the last use of each VR that is live at the operation being renamed
+
+	IR *ir.Block
 }
 
-func New(maxSR int, IR *list.List) *renamer {
+func New(maxSR int, IR *ir.Block) *renamer {
 	SRToVR := make([]int, maxSR+1)
 	LU := make([]float64, maxSR+1)
 	VRToConstant := map[int]int{}
@@ -43,65 +65,78 @@ func New(maxSR int, IR *list.List) *renamer {
 	}
 }
 
-func (r *renamer) Rename() *list.List {
-	vrName := 0
-	// var liveMap map[]
-	// curLive, maxLive := 0, 0
+// Rename renames each basic block of the IR, from the last block to the first. Live-variable analysis over the
+// control-flow graph finds the values that flow between blocks, and the definitions that reach a common use in another
+// block are grouped into a web (see findWebs). Each web gets one VR, so every path into a block agrees on where the
+// value is, while definitions of the same source register that never meet get different VRs, and a value that only
+// lives inside one block gets a new VR for each definition. The next use of a value that is live on exit is the end
+// of its block. Only the renaming is global: the allocator still handles a single block and rejects a renamed program
+// with more.
+func (r *renamer) Rename() *ir.Block {
+	blocks := cfg.New(r.IR).Blocks
+	r.live = liveVariables(blocks, r.MaxSR)
+	r.webs = findWebs(blocks, r.live)
+	r.webVR = make(map[int]int)
+	r.shared = make(map[int]bool)
+	r.blockStart = make(map[*m.OperationNode]bool)
+	r.blockEnd = make(map[*m.OperationNode]*cfg.Block)
+	for _, block := range blocks {
+		r.blockStart[block.Ops[0]] = true
+		r.blockEnd[block.Ops[len(block.Ops)-1]] = block
+	}
+
+	if r.Live {
+		r.Ranges = nil
+		r.Pressure = make([]int, r.IR.Len())
+		r.MaxLive = 0
+		r.lastUse = make(map[int]int)
+	}
+
+	r.vrName = 0
 	// go through the IR in reverse order
-	for node := r.IR.Back(); node != nil; node = node.Prev() {
-		op := node.Value.(*m.OperationNode)
+	for i := r.IR.Len() - 1; i >= 0; i-- {
+		op := r.IR.At(i)
 
 		if r.deletePrevNode {
-			r.IR.Remove(node.Next())
+			r.IR.Remove(i + 1)
 			r.deletePrevNode = false
 		}
 
+		r.startBlock(i, op)
+
 		if op.Opcode == "nop" || op.Opcode == "output" {
+			r.trackLive(i, op)
 			r.index--
+			r.endBlock(i, op)
 			continue
 		}
 
-		// same logic as definition (loadI)
-
-		// if op.Opcode == "loadI" {
-		// 	o1 := &op.OpOne
-		// 	o3 := &op.OpThree
-
-		// 	if r.SRToVR[o3.SR] == -1 {
-		// 		r.SRToVR[o3.SR] = vrName
-		// 		vrName++
-		// 	}
-		// 	o3.VR = r.SRToVR[o3.SR]
-		// 	o3.NU = r.LU[o3.SR]
-
-		// 	r.VRToConstant[o3.VR] = o1.SR
-
-		// 	r.SRToVR[o3.SR] = -1
-		// 	r.LU[o3.SR] = math.Inf(1)
-
-		// 	// mark this node for deletion
-		// 	r.deletePrevNode = true
-		// 	continue
-		// }
-
 		operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+		operands := m.Opcodes[op.Opcode].Operands
 
 		// go through each operand that is defined
 		for i, o := range operandList {
 			// skip if its not active or if its not definiition
-			if !o.Active || !isRegister(op.Opcode, i) || !isDefinition(op.Opcode, i) {
+			if !o.Active || operands[i] != m.DEF {
 				continue
 			}
 
 			if r.SRToVR[o.SR] == -1 {
-				r.SRToVR[o.SR] = vrName
-				vrName++
+				r.SRToVR[o.SR] = r.newVR()
 				// curLive += 1
 			}
 
 			o.VR = r.SRToVR[o.SR]
 			o.NU = r.LU[o.SR]
 
+			// a value from a loadI can be loaded again instead of spilled, unless its web has other definitions too
+			if op.Opcode == "loadI" && !r.shared[o.VR] {
+				r.VRToConstant[o.VR] = op.OpOne.SR
+			}
+
+			if _, ok := r.defsAbove[o.SR]; ok {
+				r.defsAbove[o.SR]--
+			}
 			r.SRToVR[o.SR] = -1
 			r.LU[o.SR] = math.Inf(1)
 		}
@@ -109,13 +144,12 @@ func (r *renamer) Rename() *list.List {
 		// go through each operand that is used
 		for i, o := range operandList {
 			// skip if its not active, valid, or if its a definiition
-			if !o.Active || !isRegister(op.Opcode, i) || isDefinition(op.Opcode, i) {
+			if !o.Active || operands[i] != m.USE {
 				continue
 			}
 
 			if r.SRToVR[o.SR] == -1 {
-				r.SRToVR[o.SR] = vrName
-				vrName++
+				r.SRToVR[o.SR] = r.useVR(o.SR)
 			}
 
 			o.VR = r.SRToVR[o.SR]
@@ -125,44 +159,95 @@ func (r *renamer) Rename() *list.List {
 		// go through each operand that is used
 		for i, o := range operandList {
 			// skip if its not active or if its a definiition
-			if !o.Active || !isRegister(op.Opcode, i) || isDefinition(op.Opcode, i) {
+			if !o.Active || operands[i] != m.USE {
 				continue
 			}
 
 			r.LU[o.SR] = float64(r.index)
 		}
 
-		if r.MaxVR < vrName {
-			r.MaxVR = vrName
-		}
-
+		r.trackLive(i, op)
 		r.index--
+		r.endBlock(i, op)
+	}
+	r.finishLive()
+	r.MaxVR = r.vrName
+
+	return r.IR
+}
+
+// newVR hands out the next VR
+func (r *renamer) newVR() int {
+	r.vrName++
+	return r.vrName - 1
+}
+
+// useVR names the value read by the last use of a register in the current block that was not renamed yet. If nothing
+// above the use in the block writes the register, the value came in from another block and is named after its web.
+func (r *renamer) useVR(sr int) int {
+	node, live := r.webs.in[r.block.ID][sr]
+	if !live || r.defsAbove[sr] > 0 {
+		return r.newVR()
 	}
 
-	// node := r.IR.Front()
-	// op := node.Value.(*m.OperationNode)
+	return r.webName(node)
+}
 
-	// if op.Opcode == "loadI" {
-	// 	r.IR.Remove(node)
-	// }
+// webName returns the VR of the web the node is in, handing one out the first time the web is seen
+func (r *renamer) webName(node int) int {
+	root := r.webs.find(node)
+	if vr, ok := r.webVR[root]; ok {
+		return vr
+	}
 
-	// fmt.Printf("VR to Constant", r.VRToConstant, "\n")
-	return r.IR
+	vr := r.newVR()
+	r.webVR[root] = vr
+	if r.webs.defs[root] > 1 {
+		r.shared[vr] = true
+	}
+
+	return vr
 }
 
-func isDefinition(opcode string, i int) bool {
-	if opcode == "store" {
-		return false
-	} else if i == 2 {
-		return true
+// startBlock names every value live on exit from a block after its web and sets its next use to just past the end of
+// the block, before its last operation is renamed, so the last use of such a value inside the block is not mistaken
+// for its last use anywhere. It also counts the definitions in the block of each register live on entry to it, and
+// starts the live ranges of the values live on exit.
+func (r *renamer) startBlock(i int, op *m.OperationNode) {
+	block := r.blockEnd[op]
+	if block == nil {
+		return
+	}
+	r.block = block
+
+	// in register order, so the VRs are numbered the same way on every run
+	for _, sr := range slices.Sorted(maps.Keys(r.webs.out[block.ID])) {
+		r.SRToVR[sr] = r.webName(r.webs.out[block.ID][sr])
+		r.LU[sr] = float64(r.index + 1)
+	}
+	r.liveOut(i, block)
+
+	r.defsAbove = make(map[int]int)
+	for _, op := range block.Ops {
+		operands := m.Opcodes[op.Opcode].Operands
+		for i, o := range []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree} {
+			if _, live := r.webs.in[block.ID][o.SR]; live && o.Active && operands[i] == m.DEF {
+				r.defsAbove[o.SR]++
+			}
+		}
 	}
-	return false
 }
 
-func isRegister(opcode string, i int) bool {
-	if (opcode == "loadI" && i == 0) || (opcode == "output" && i == 2) {
-		return false
+// endBlock forgets the names and next uses of the values once the first operation of a block, at position i, has been
+// renamed; the values live on entry were named after their webs, and next uses are only measured within a block
+func (r *renamer) endBlock(i int, op *m.OperationNode) {
+	if !r.blockStart[op] {
+		return
 	}
+	r.liveIn(i)
 
-	return true
+	for sr := 0; sr <= r.MaxSR; sr++ {
+		r.SRToVR[sr] = -1
+		r.LU[sr] = math.Inf(1)
+	}
 }
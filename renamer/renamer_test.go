@@ -0,0 +1,157 @@
+package renamer
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+	p "github.com/bivguy/Comp412/parser"
+	s "github.com/bivguy/Comp412/scanner"
+	"github.com/bivguy/Comp412/simulator"
+)
+
+// TestCase renames a program, then checks how many VRs each source register was renamed to and that the renamed
+// program prints the same outputs as the original
+type TestCase struct {
+	description     string
+	input           string
+	expectedVRs     map[int]int // the number of different VRs each source register was renamed to
+	expectedOutputs []int32
+}
+
+// LiveTestCase renames a program and checks its live ranges, as the Def and LastUse of each in the order the renamer
+// gives them, and the pressure at each operation
+type LiveTestCase struct {
+	description      string
+	input            string
+	expectedRanges   [][2]int
+	expectedPressure []int
+	expectedMaxLive  int
+}
+
+var testCases = []TestCase{
+	{
+		description: "values live around a loop",
+		input: "loadI 1024 => r1\nloadI 3 => r3\nL1: cbr r3 -> L2, L4\n" +
+			"L2: loadI 4 => r2\nstore r2 => r1\nsubI r3, 1 => r3\ncbr r3 -> L3, L4\n" +
+			"L3: loadI 8 => r2\nadd r1, r2 => r1\njumpI -> L1\n" +
+			"L4: store r3 => r1\noutput 1024\noutput 1040\n",
+		// r1 and r3 are carried around the loop, while r2 only lives inside L2 and inside L3
+		expectedVRs:     map[int]int{1: 1, 2: 2, 3: 1},
+		expectedOutputs: []int32{4, 0},
+	},
+	{
+		description: "unrelated values of a register that cross blocks",
+		input: "loadI 1024 => r1\nloadI 4 => r2\njumpI -> L1\n" +
+			"L1: store r2 => r1\nloadI 8 => r2\njumpI -> L2\n" +
+			"L2: add r2, r2 => r3\nstore r3 => r1\noutput 1024\n",
+		// the r2 written before L1 and the r2 written in L1 never reach the same use
+		expectedVRs:     map[int]int{1: 1, 2: 2, 3: 1},
+		expectedOutputs: []int32{16},
+	},
+	{
+		description: "definitions that reach a common use",
+		input: "loadI 1024 => r1\nloadI 0 => r2\ncbr r2 -> L1, L2\n" +
+			"L1: loadI 4 => r3\njumpI -> L3\n" +
+			"L2: loadI 8 => r3\n" +
+			"L3: store r3 => r1\noutput 1024\n",
+		// both values of r3 reach the store, so they are one web
+		expectedVRs:     map[int]int{1: 1, 2: 1, 3: 1},
+		expectedOutputs: []int32{8},
+	},
+}
+
+var liveTestCases = []LiveTestCase{
+	{
+		description: "single block",
+		input:       "loadI 4 => r1\nloadI 8 => r2\nadd r1, r2 => r3\nstore r3 => r1\naddI r9, 1 => r5\n",
+		// r9 is live on entry and r5 is never read
+		expectedRanges:   [][2]int{{-1, 4}, {0, 3}, {1, 2}, {2, 3}, {4, -1}},
+		expectedPressure: []int{1, 2, 3, 3, 1},
+		expectedMaxLive:  3,
+	},
+	{
+		description: "values carried around a loop",
+		input:       "loadI 0 => r1\nloadI 10 => r2\nL1: addI r1, 1 => r1\ncmp_LT r1, r2 => r3\ncbr r3 -> L1, L2\nL2: output 0\n",
+		// r1 and r2 are live to the end of the entry block and of the loop, and into the loop from either
+		expectedRanges:   [][2]int{{0, 1}, {1, 2}, {1, 4}, {1, 1}, {2, 4}, {3, 4}},
+		expectedPressure: []int{0, 1, 2, 2, 3, 0},
+		expectedMaxLive:  3,
+	},
+}
+
+func TestRename(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parsed, renamed := rename(t, tc.input)
+
+			names := make(map[int][]int)
+			for _, op := range renamed.Ops() {
+				for i, o := range []m.Operand{op.OpOne, op.OpTwo, op.OpThree} {
+					if o.Active && m.IsRegister(op.Opcode, i) && !slices.Contains(names[o.SR], o.VR) {
+						names[o.SR] = append(names[o.SR], o.VR)
+					}
+				}
+			}
+			for sr, expected := range tc.expectedVRs {
+				if len(names[sr]) != expected {
+					t.Errorf("expected r%d to be renamed to %d VRs but got %v", sr, expected, names[sr])
+				}
+			}
+
+			for _, stage := range []struct {
+				IR          *ir.Block
+				registerSet simulator.RegisterSet
+			}{{parsed, simulator.SOURCE}, {renamed, simulator.VIRTUAL}} {
+				sim := simulator.New(stage.registerSet, nil)
+				if err := sim.Run(stage.IR); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(tc.expectedOutputs, sim.Outputs()) {
+					t.Errorf("expected outputs %v but got %v", tc.expectedOutputs, sim.Outputs())
+				}
+			}
+		})
+	}
+}
+
+func TestLive(t *testing.T) {
+	for _, tc := range liveTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parser := p.New(s.New(strings.NewReader(tc.input)))
+			IR, _, err := parser.Parse()
+			if err != nil {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+
+			renamer := New(parser.GetLargestRegister(), IR)
+			renamer.Live = true
+			renamer.Rename()
+
+			var ranges [][2]int
+			for _, lr := range renamer.Ranges {
+				ranges = append(ranges, [2]int{lr.Def, lr.LastUse})
+			}
+			if !reflect.DeepEqual(tc.expectedRanges, ranges) {
+				t.Errorf("expected live ranges %v but got %v", tc.expectedRanges, ranges)
+			}
+			if !reflect.DeepEqual(tc.expectedPressure, renamer.Pressure) || renamer.MaxLive != tc.expectedMaxLive {
+				t.Errorf("expected pressure %v with MaxLive %d but got %v with MaxLive %d", tc.expectedPressure, tc.expectedMaxLive, renamer.Pressure, renamer.MaxLive)
+			}
+		})
+	}
+}
+
+// rename parses the input and returns it as parsed and as renamed
+func rename(t *testing.T, input string) (*ir.Block, *ir.Block) {
+	parser := p.New(s.New(strings.NewReader(input)))
+	IR, _, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	return IR, New(parser.GetLargestRegister(), IR.Copy()).Rename()
+}
@@ -0,0 +1,102 @@
+package renamer
+
+import (
+	"github.com/bivguy/Comp412/cfg"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// webs groups the values that cross block boundaries into webs, so each web can be renamed to its own VR. The value of
+// a register live on entry to a block is one node, and so is the last definition of a register live on exit. A value
+// live on entry holds whatever reaches the end of each predecessor, so its node is joined with theirs; once every
+// edge has been visited, each set of joined nodes is one web: the definitions that reach a common use, together with
+// the uses they reach in other blocks. Definitions of the same register that never meet stay in separate webs.
+type webs struct {
+	parent []int
+	defs   []int // for each node that is the root of its set, the number of definitions in the web
+
+	in  []map[int]int // for each block by its ID, the node of each register live on entry
+	out []map[int]int // for each block, the node of each register live on exit
+}
+
+// findWebs builds the webs of a program from the liveness of its blocks
+func findWebs(blocks []*cfg.Block, live liveness) *webs {
+	w := &webs{in: make([]map[int]int, len(blocks)), out: make([]map[int]int, len(blocks))}
+
+	for _, block := range blocks {
+		in, out := make(map[int]int), make(map[int]int)
+		for sr, l := range live.in[block.ID] {
+			if l {
+				in[sr] = w.node(0)
+			}
+		}
+
+		defined := definedRegisters(block)
+		for sr, l := range live.out[block.ID] {
+			if !l {
+				continue
+			}
+
+			// a register the block does not write leaves it with the value it came in with
+			if defined[sr] {
+				out[sr] = w.node(1)
+			} else {
+				out[sr] = in[sr]
+			}
+		}
+
+		w.in[block.ID], w.out[block.ID] = in, out
+	}
+
+	// a register live on entry to a block is live on exit from each of its predecessors
+	for _, block := range blocks {
+		for sr, node := range w.in[block.ID] {
+			for _, pred := range block.Preds {
+				w.union(node, w.out[pred.ID][sr])
+			}
+		}
+	}
+
+	return w
+}
+
+// node adds a node in a set of its own, counting defs definitions
+func (w *webs) node(defs int) int {
+	w.parent = append(w.parent, len(w.parent))
+	w.defs = append(w.defs, defs)
+	return len(w.parent) - 1
+}
+
+// find returns the root of the web the node is in
+func (w *webs) find(node int) int {
+	for w.parent[node] != node {
+		w.parent[node] = w.parent[w.parent[node]]
+		node = w.parent[node]
+	}
+
+	return node
+}
+
+func (w *webs) union(a int, b int) {
+	a, b = w.find(a), w.find(b)
+	if a == b {
+		return
+	}
+
+	w.parent[b] = a
+	w.defs[a] += w.defs[b]
+}
+
+// definedRegisters finds the registers a block writes
+func definedRegisters(block *cfg.Block) map[int]bool {
+	defined := make(map[int]bool)
+	for _, op := range block.Ops {
+		operands := m.Opcodes[op.Opcode].Operands
+		for i, o := range []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree} {
+			if o.Active && operands[i] == m.DEF {
+				defined[o.SR] = true
+			}
+		}
+	}
+
+	return defined
+}
@@ -2,10 +2,10 @@ package scanner
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	. "github.com/bivguy/Comp412/constants"
 	"github.com/bivguy/Comp412/models"
@@ -19,14 +19,26 @@ type scanner struct {
 	lineLength int
 	lineEnd    bool
 	lineNumber int
+
+	commentText string
 }
 
-func New(file *os.File) *scanner {
-	lineReader := bufio.NewReader(file)
+// New creates a scanner that reads ILOC from r, which may be a file, os.Stdin, a strings.Reader or any other reader
+func New(r io.Reader) *scanner {
+	lineReader := bufio.NewReader(r)
 
 	return &scanner{curIdx: -1, startIdx: -1, lineNumber: 0, lineReader: lineReader, lineEnd: true}
 }
 
+// NewAt creates a scanner for a part of a larger input that starts at the given line, so its tokens and errors have
+// the line numbers of the whole input
+func NewAt(r io.Reader, line int) *scanner {
+	s := New(r)
+	s.lineNumber = line - 1
+
+	return s
+}
+
 func (s *scanner) NextToken() (models.Token, error) {
 	category := INVALID
 	var lexeme string
@@ -56,103 +68,29 @@ func (s *scanner) NextToken() (models.Token, error) {
 	}
 	s.startIdx = s.curIdx // mark the beginning of the current lexeme
 
-	// figure out what to do with the character
-	switch {
-	// if it starts with an l, the word can be load, loadI, or lshift
-	case c == 'l':
-		c, err = s.next()
-		if err != nil {
-			s.lineEnd = true
-			return models.Token{Category: INVALID, Lexeme: "", LineNumber: s.lineNumber}, err
-		}
-		switch {
-		// if the next letter is an o, it must be load or loadI
-		case c == 'o':
-			category, err = s.loadHelper()
-		// if the next letter is an s, it must be lshift
-		case c == 's':
-			category, err = s.lshiftHelper()
-		default:
-			err = errors.New("invalid instruction: letter 'o' or 's' expected but found " + string(c))
-		}
-	case c == '\r': // TODO: this operates under the assumption that a \n will follow and that this is only in windows
-		category = EOL
-		s.lineEnd = true
-	case c == '\n':
-		category = EOL
-		s.lineEnd = true
-	// if it starts with an s, indicates store or sub
-	case c == 's':
-		c, err = s.next()
-		if err != nil {
-			s.lineEnd = true
-			return models.Token{Category: INVALID, Lexeme: "", LineNumber: s.lineNumber}, err
-		}
-		switch {
-		// if the next letter is a t, it must be store
-		case c == 't':
-			category, err = s.storeHelper()
-		// if the next letter is a u, it must be sub
-		case c == 'u':
-			category, err = s.subHelper()
-		default:
-			err = fmt.Errorf("scanner invalid instruction: found %q at line %d", c, s.lineNumber)
-		}
-	case c == 'a':
-		category, err = s.addHelper()
-	case c == 'm':
-		category, err = s.multHelper()
-	case c == 'o':
-		category, err = s.outputHelper()
-	case c == 'n':
-		category, err = s.nopHelper()
-	case c == ',':
-		category = COMMA
-	case c == '=':
-		category, err = s.intoHelper()
-	case c == '/':
-		category, err = s.commentHelper()
-		if err != nil {
-			s.lineEnd = true
-			return models.Token{Category: INVALID, Lexeme: "", LineNumber: s.lineNumber}, err
-		}
-
-		return models.Token{Category: category, Lexeme: "//", LineNumber: s.lineNumber}, nil
-	// if it starts with an r, it indicates a register or rshift
-	case c == 'r':
-		c, err = s.next()
-		if err != nil {
-			s.lineEnd = true
-			return models.Token{Category: INVALID, Lexeme: "", LineNumber: s.lineNumber}, err
-		}
-
-		switch {
-		// if the next letter is an s, it must be rshift
-		case c == 's':
-			category, err = s.rshiftHelper()
-		// if the next letter is a digit, it must be a register
-		case c >= '0' && c <= '9':
-			category, err = s.registerHelper(c)
-		default:
-			err = fmt.Errorf("scanner invalid instruction: letter 't' or 'u' expected but found %q at line %d", c, s.lineNumber)
+	// run the transition table to find the rest of the token
+	category, err = s.match(c)
 
-		}
-	default:
-		// check if the starting character is a integer (for constants)
-		if c >= '0' && c <= '9' {
-			category, err = s.constantHelper(c)
-		} else {
-			err = fmt.Errorf("unrecognized instruction: %q at line %d", c, s.lineNumber)
+	if err != nil {
+		s.lineEnd = true
+		// a malformed comment has always been reported on its own, without the scanner's location
+		if c != '/' {
+			err = s.diagnostic(err)
 		}
 	}
 
-	if err != nil {
+	switch category {
+	case EOL:
+		s.lineEnd = true
+	case COMMENT:
+		// keep the comment's text and skip to the next line when we want the next token
+		s.commentText = strings.TrimRight(s.lineText[s.curIdx+1:], "\r\n")
 		s.lineEnd = true
-		err = fmt.Errorf("scanner error at line %d: %w ", s.lineNumber, err)
 	}
 
 	lexeme = s.lineText[s.startIdx : s.curIdx+1]
-	return models.Token{Category: category, Lexeme: lexeme, LineNumber: s.lineNumber}, err
+	start, end := s.Columns()
+	return models.Token{Category: category, Lexeme: lexeme, LineNumber: s.lineNumber, StartColumn: start, EndColumn: end}, err
 }
 
 // This function initializes the scanner state for a new line. It returns true if there is a new line to process, false otherwise.
@@ -195,13 +133,48 @@ func (s *scanner) skipWhitespace() {
 }
 
 func (s *scanner) PrintToken(token models.Token) {
-	fmt.Printf("<%v, %q> at line %d\n", SyntacticCategories[token.Category], token.Lexeme, token.LineNumber)
+	s.WriteToken(os.Stdout, token)
+}
+
+// WriteToken writes the token to w the way PrintToken prints it
+func (s *scanner) WriteToken(w io.Writer, token models.Token) {
+	fmt.Fprintf(w, "<%v, %q> at line %d\n", SyntacticCategories[token.Category], token.Lexeme, token.LineNumber)
 }
 
 func (s *scanner) SetNextLine() {
 	s.lineEnd = true
 }
 
+// CommentText returns the text after the '//' of the most recently scanned comment
+func (s *scanner) CommentText() string {
+	return s.commentText
+}
+
+// Source returns the text of the line being scanned, without its line ending
+func (s *scanner) Source() string {
+	return strings.TrimRight(s.lineText, "\r\n")
+}
+
+// Columns returns the 1-based columns of the first and last characters of the token most recently scanned
+func (s *scanner) Columns() (int, int) {
+	return s.startIdx + 1, max(s.curIdx, s.startIdx) + 1
+}
+
+// diagnostic locates an error at the token being scanned, up to the character that could not be matched
+func (s *scanner) diagnostic(err error) models.Diagnostic {
+	start, end := s.Columns()
+
+	return models.Diagnostic{
+		Severity: models.ERROR,
+		Line:     s.lineNumber,
+		Column:   start,
+		Span:     end - start + 1,
+		Code:     models.CodeInvalidToken,
+		Message:  err.Error(),
+		Source:   s.Source(),
+	}
+}
+
 func (s *scanner) GetCurrentLine() int {
 	return s.lineNumber
 }
@@ -1,7 +1,9 @@
 package scanner
 
 import (
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -143,6 +145,33 @@ var simpleTestCases = []TestCase{
 		},
 		expectedError: true,
 	},
+	{
+		description: "Valid scanner input with bundles of operations",
+		input:       "scanner_tests/simple_tests/scanner_test10.txt",
+		expectedTokens: []models.Token{
+			{Category: LBRACKET, Lexeme: "["},
+			{Category: LOADI, Lexeme: "loadI"},
+			{Category: CONSTANT, Lexeme: "7"},
+			{Category: INTO, Lexeme: "=>"},
+			{Category: REGISTER, Lexeme: "r1"},
+			{Category: SEMICOLON, Lexeme: ";"},
+			{Category: MEMOP, Lexeme: "load"},
+			{Category: REGISTER, Lexeme: "r2"},
+			{Category: INTO, Lexeme: "=>"},
+			{Category: REGISTER, Lexeme: "r3"},
+			{Category: RBRACKET, Lexeme: "]"},
+			{Category: EOL, Lexeme: "\n"},
+			{Category: LBRACKET, Lexeme: "["},
+			{Category: OUTPUT, Lexeme: "output"},
+			{Category: CONSTANT, Lexeme: "4"},
+			{Category: SEMICOLON, Lexeme: ";"},
+			{Category: NOP, Lexeme: "nop"},
+			{Category: RBRACKET, Lexeme: "]"},
+			{Category: COMMENT, Lexeme: "//"},
+			{Category: EOF, Lexeme: ""},
+		},
+		expectedError: false,
+	},
 }
 
 var complexTestCases = []TestCase{
@@ -170,7 +199,10 @@ var complexTestCases = []TestCase{
 			{Category: COMMENT, Lexeme: "//"},
 			{Category: COMMENT, Lexeme: "//"},
 
-			{Category: INVALID, Lexeme: "addI"},
+			{Category: IMMOP, Lexeme: "addI"},
+			{Category: REGISTER, Lexeme: "r1"},
+			{Category: COMMA, Lexeme: ","},
+			{Category: INVALID, Lexeme: "ra"},
 			{Category: EOF, Lexeme: ""},
 		},
 		expectedError: true,
@@ -562,6 +594,53 @@ var complexTestCases = []TestCase{
 	},
 }
 
+// inlineTestCases scan their input text directly instead of opening a file
+var inlineTestCases = []TestCase{
+	{
+		description: "operation from a string",
+		input:       "loadI 4 => r1\n",
+		expectedTokens: []models.Token{
+			{Category: LOADI, Lexeme: "loadI"},
+			{Category: CONSTANT, Lexeme: "4"},
+			{Category: INTO, Lexeme: "=>"},
+			{Category: REGISTER, Lexeme: "r1"},
+			{Category: EOL, Lexeme: "\n"},
+			{Category: EOF, Lexeme: ""},
+		},
+	},
+	{
+		description: "last line without a newline",
+		input:       "// comment\noutput 1024",
+		expectedTokens: []models.Token{
+			{Category: COMMENT, Lexeme: "//"},
+			{Category: OUTPUT, Lexeme: "output"},
+			{Category: CONSTANT, Lexeme: "1024"},
+			{Category: EOF, Lexeme: ""},
+		},
+	},
+	{
+		description: "columns",
+		input:       "\tadd r1,  r22 => r3 // sum\n",
+		expectedTokens: []models.Token{
+			{Category: ARITHOP, Lexeme: "add", StartColumn: 2, EndColumn: 4},
+			{Category: REGISTER, Lexeme: "r1", StartColumn: 6, EndColumn: 7},
+			{Category: COMMA, Lexeme: ",", StartColumn: 8, EndColumn: 8},
+			{Category: REGISTER, Lexeme: "r22", StartColumn: 11, EndColumn: 13},
+			{Category: INTO, Lexeme: "=>", StartColumn: 15, EndColumn: 16},
+			{Category: REGISTER, Lexeme: "r3", StartColumn: 18, EndColumn: 19},
+			{Category: COMMENT, Lexeme: "//", StartColumn: 21, EndColumn: 22},
+			{Category: EOF, Lexeme: ""},
+		},
+	},
+	{
+		description: "empty input",
+		input:       "",
+		expectedTokens: []models.Token{
+			{Category: EOF, Lexeme: ""},
+		},
+	},
+}
+
 var performanceTestCases = []PerformanceTestCase{
 	{
 		description:   "Performance Test 1",
@@ -586,6 +665,63 @@ func TestComplexScannerTestCases(t *testing.T) {
 	}
 }
 
+func TestInlineScannerTestCases(t *testing.T) {
+	for _, tc := range inlineTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			checkTokens(tc, strings.NewReader(tc.input), t)
+		})
+	}
+}
+
+// TestTokenSpecs scans every word in the token table on its own and checks that it gets the table's category
+func TestTokenSpecs(t *testing.T) {
+	for _, spec := range tokenSpecs {
+		word := strings.NewReplacer("#", "12", "*", "1_loop").Replace(spec.pattern)
+		t.Run(strings.TrimSpace(word), func(t *testing.T) {
+			token, err := New(strings.NewReader(word + " ")).NextToken()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if token.Category != spec.category || token.Lexeme != word {
+				t.Errorf("expected <%v, %q> but got <%v, %q>", spec.category, word, token.Category, token.Lexeme)
+			}
+		})
+	}
+}
+
+type ErrorTestCase struct {
+	description   string
+	input         string
+	expectedError string // the whole message; a scanner error is a diagnostic, except for a malformed comment
+}
+
+var errorTestCases = []ErrorTestCase{
+	{"misspelled opcode", "lshfit r1", "ERROR 1: invalid lshift instruction: letter 'i' expected but found f"},
+	{"shared prefix", "lx", "ERROR 1: invalid instruction: letter 'o' or 's' expected but found x"},
+	{"store or sub", "sx", "ERROR 1: scanner invalid instruction: found 'x' at line 1"},
+	{"opcode followed by a letter", "nopx", "ERROR 1: invalid nop instruction: unexpected character found after 'nop'"},
+	{"opcode that another opcode extends", "addx", "ERROR 1: invalid add instruction: unexpected character found after 'add'"},
+	{"store followed by a letter", "storeabc", "ERROR 1: invalid store instruction: unexpected character found after 'store'"},
+	{"load followed by a letter", "loadx", "ERROR 1: invalid load instruction: invalid character after load: x"},
+	{"constant followed by a letter", "12a", "ERROR 1: invalid constant: whitespace or end of line expected but found a"},
+	{"into", "=x", "ERROR 1: invalid 'into' instruction: letter '>' expected but found x"},
+	{"arrow", "-x", "ERROR 1: invalid 'arrow' instruction: letter '>' expected but found x"},
+	{"label followed by a symbol", "L1=", "ERROR 1: invalid label: whitespace or end of line expected but found ="},
+	{"comment", "/x", "invalid comment: expected another '/' but found x"},
+	{"unknown character", "#", "ERROR 1: unrecognized instruction: '#' at line 1"},
+}
+
+func TestErrorMessages(t *testing.T) {
+	for _, tc := range errorTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := New(strings.NewReader(tc.input + "\n")).NextToken()
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("expected error %q but got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
 func TestScannerPerformance(t *testing.T) {
 	for _, tc := range performanceTestCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -626,7 +762,12 @@ func runTest(tc TestCase, t *testing.T) {
 	}
 	defer file.Close()
 
-	scanner := New(file)
+	checkTokens(tc, file, t)
+}
+
+// checkTokens scans everything in r and compares the tokens with the ones the test case expects
+func checkTokens(tc TestCase, r io.Reader, t *testing.T) {
+	scanner := New(r)
 	var tokens []models.Token
 	for {
 		tok, err := scanner.NextToken()
@@ -655,5 +796,9 @@ func runTest(tc TestCase, t *testing.T) {
 		if curToken.Lexeme != expected.Lexeme {
 			t.Errorf("Token %d - expected lexeme %+v, got %+v", i, expected.Lexeme, curToken.Lexeme)
 		}
+
+		if expected.StartColumn != 0 && (curToken.StartColumn != expected.StartColumn || curToken.EndColumn != expected.EndColumn) {
+			t.Errorf("Token %d - expected columns %d-%d, got %d-%d", i, expected.StartColumn, expected.EndColumn, curToken.StartColumn, curToken.EndColumn)
+		}
 	}
 }
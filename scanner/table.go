@@ -0,0 +1,295 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/bivguy/Comp412/constants"
+	"github.com/bivguy/Comp412/models"
+)
+
+// characters that may directly follow a token without a space in between
+const (
+	wordEndings     = " \t\r\n/;]"
+	constantEndings = " \t\r\n=/;]"
+	registerEndings = " \t\r\n,=/;]"
+	labelEndings    = " \t\r\n:,/;]"
+)
+
+// identifierCharacters may appear in a label after its leading 'L'
+const identifierCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+// tokenSpec describes one token the scanner recognizes. The transition table is built from these, so adding an
+// opcode only needs a new entry in tokenSpecs.
+type tokenSpec struct {
+	pattern  string // the exact text of the token; a trailing '#' matches one or more digits and a trailing '*' any identifier characters
+	category models.SyntacticCategory
+	name     string // how errors refer to the token, if not by its pattern
+	endings  string // the characters that may follow the token; if empty, the token ends as soon as it is matched
+
+	mismatch string // the error for an unexpected character inside the token, given its name, the expected letter and what was found
+	ending   string // the error for a character that cannot follow the complete token, given its name, its pattern and what was found
+}
+
+var tokenSpecs = []tokenSpec{
+	{pattern: "load", category: MEMOP, endings: wordEndings, ending: "invalid %[1]s instruction: invalid character after %[2]s: %[3]s"},
+	{pattern: "loadI", category: LOADI, endings: wordEndings},
+	{pattern: "loadAI", category: IMMOP, endings: wordEndings},
+	{pattern: "loadAO", category: ARITHOP, endings: wordEndings},
+	{pattern: "store", category: MEMOP, endings: wordEndings},
+	{pattern: "storeAI", category: STOREAI, endings: wordEndings},
+	{pattern: "storeAO", category: STOREAO, endings: wordEndings},
+	{pattern: "i2i", category: MEMOP, endings: wordEndings},
+	{pattern: "add", category: ARITHOP, endings: wordEndings},
+	{pattern: "sub", category: ARITHOP, endings: wordEndings},
+	{pattern: "mult", category: ARITHOP, endings: wordEndings},
+	{pattern: "div", category: ARITHOP, endings: wordEndings},
+	{pattern: "lshift", category: ARITHOP, endings: wordEndings},
+	{pattern: "rshift", category: ARITHOP, endings: wordEndings},
+	{pattern: "addI", category: IMMOP, endings: wordEndings},
+	{pattern: "subI", category: IMMOP, endings: wordEndings},
+	{pattern: "multI", category: IMMOP, endings: wordEndings},
+	{pattern: "divI", category: IMMOP, endings: wordEndings},
+	{pattern: "lshiftI", category: IMMOP, endings: wordEndings},
+	{pattern: "rshiftI", category: IMMOP, endings: wordEndings},
+	{pattern: "cmp_LT", category: ARITHOP, endings: wordEndings},
+	{pattern: "cmp_LE", category: ARITHOP, endings: wordEndings},
+	{pattern: "cmp_EQ", category: ARITHOP, endings: wordEndings},
+	{pattern: "cmp_GE", category: ARITHOP, endings: wordEndings},
+	{pattern: "cmp_GT", category: ARITHOP, endings: wordEndings},
+	{pattern: "cmp_NE", category: ARITHOP, endings: wordEndings},
+	{pattern: "output", category: OUTPUT, endings: wordEndings},
+	{pattern: "nop", category: NOP, endings: wordEndings},
+	{pattern: "jumpI", category: JUMPI, endings: wordEndings},
+	{pattern: "jump", category: JUMP, endings: wordEndings},
+	{pattern: "cbr", category: CBR, endings: wordEndings},
+
+	{pattern: "r#", category: REGISTER, name: "constant", endings: registerEndings},
+	{pattern: "#", category: CONSTANT, name: "constant", endings: constantEndings},
+	{pattern: "L*", category: LABEL, name: "label", endings: labelEndings},
+
+	{pattern: "=>", category: INTO, name: "'into'"},
+	{pattern: "->", category: ARROW, name: "'arrow'"},
+	{pattern: ":", category: COLON},
+	{pattern: ",", category: COMMA},
+	{pattern: "[", category: LBRACKET},
+	{pattern: ";", category: SEMICOLON},
+	{pattern: "]", category: RBRACKET},
+	{pattern: "\n", category: EOL},
+	{pattern: "\r", category: EOL},
+	{pattern: "//", category: COMMENT, name: "comment", mismatch: "invalid %s: expected another '%c' but found %s"},
+}
+
+const defaultMismatch = "invalid %s instruction: letter '%c' expected but found %s"
+const defaultEnding = "invalid %[1]s instruction: unexpected character found after '%[2]s'"
+
+// prefixErrors are the errors for the prefixes shared by tokens that continue differently, given the character
+// that was found and the line. Other shared prefixes list the letters that could have come next.
+var prefixErrors = map[string]string{
+	"s": "scanner invalid instruction: found %[1]q at line %[2]d",
+	"r": "scanner invalid instruction: letter 't' or 'u' expected but found %[1]q at line %[2]d",
+}
+
+// noTransition marks a character that cannot continue the token in a state
+const noTransition = -1
+
+// state is one state of the scanner's DFA: the prefix of a token that has been read so far
+type state struct {
+	next   [256]int // the state after reading each character, or noTransition
+	accept *tokenSpec
+	prefix string
+	specs  []*tokenSpec // every token that starts with this prefix, in the order of tokenSpecs
+	final  bool         // the token is complete as soon as this state is reached
+}
+
+var table = buildTable(tokenSpecs)
+
+// buildTable builds the DFA for the given tokens as a trie of their patterns; state 0 is the start state
+func buildTable(specs []tokenSpec) []state {
+	states := []state{newState()}
+
+	for i := range specs {
+		spec := &specs[i]
+		current := 0
+
+		for j := 0; j < len(spec.pattern); j++ {
+			c := spec.pattern[j]
+			states[current].specs = append(states[current].specs, spec)
+
+			if c == '#' {
+				if j != len(spec.pattern)-1 {
+					panic(fmt.Sprintf("scanner: '#' must end the pattern of %q", spec.pattern))
+				}
+				digits := addState(&states, spec.pattern[:j]+"#")
+				for d := '0'; d <= '9'; d++ {
+					states[current].next[d] = digits
+					states[digits].next[d] = digits
+				}
+				current = digits
+				continue
+			}
+
+			if c == '*' {
+				if j != len(spec.pattern)-1 {
+					panic(fmt.Sprintf("scanner: '*' must end the pattern of %q", spec.pattern))
+				}
+				for k := 0; k < len(identifierCharacters); k++ {
+					states[current].next[identifierCharacters[k]] = current
+				}
+				continue
+			}
+
+			if states[current].next[c] == noTransition {
+				states[current].next[c] = addState(&states, spec.pattern[:j+1])
+			}
+			current = states[current].next[c]
+		}
+
+		if states[current].accept != nil {
+			panic(fmt.Sprintf("scanner: tokens %q and %q overlap", states[current].accept.pattern, spec.pattern))
+		}
+		states[current].accept = spec
+		states[current].specs = append(states[current].specs, spec)
+	}
+
+	for i := range states {
+		states[i].final = states[i].accept != nil && states[i].accept.endings == "" && !states[i].hasTransitions()
+	}
+
+	return states
+}
+
+func newState() state {
+	var s state
+	for i := range s.next {
+		s.next[i] = noTransition
+	}
+
+	return s
+}
+
+func addState(states *[]state, prefix string) int {
+	st := newState()
+	st.prefix = prefix
+	*states = append(*states, st)
+	return len(*states) - 1
+}
+
+// match runs the DFA from the first character of a token, leaving curIdx on the token's last character
+func (s *scanner) match(c byte) (models.SyntacticCategory, error) {
+	current := table[0].next[c]
+	if current == noTransition {
+		return INVALID, fmt.Errorf("unrecognized instruction: %q at line %d", c, s.lineNumber)
+	}
+
+	for {
+		st := &table[current]
+		if st.final {
+			return st.accept.category, nil
+		}
+
+		c, err := s.next()
+		// reaching the end of the line ends the token where it is
+		if err != nil {
+			s.curIdx--
+			if st.accept != nil {
+				return st.accept.category, nil
+			}
+			return INVALID, st.mismatch(0, s.lineNumber)
+		}
+
+		if next := st.next[c]; next != noTransition {
+			current = next
+			continue
+		}
+
+		if st.accept == nil {
+			return INVALID, st.mismatch(c, s.lineNumber)
+		}
+
+		if st.accept.endings == "" || strings.IndexByte(st.accept.endings, c) >= 0 {
+			s.curIdx-- // step back one character since the one we read belongs to the next token
+			return st.accept.category, nil
+		}
+
+		return INVALID, st.invalidEnding(string(c))
+	}
+}
+
+func (st *state) hasTransitions() bool {
+	for _, next := range st.next {
+		if next != noTransition {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expected lists the characters that can continue the token, with the digits listed once
+func (st *state) expected() []string {
+	var expected []string
+
+	for c, next := range st.next {
+		switch {
+		case next == noTransition:
+		case c >= '0' && c <= '9':
+			if c == '0' {
+				expected = append(expected, "a digit")
+			}
+		default:
+			expected = append(expected, fmt.Sprintf("'%c'", c))
+		}
+	}
+
+	return expected
+}
+
+// mismatch reports a character that cannot continue the token, where 0 means the end of the file was reached. If the
+// prefix is shared by tokens that continue differently, the error lists every letter that could have come next.
+func (st *state) mismatch(c byte, line int) error {
+	found := string(c)
+	if c == 0 {
+		found = "end of file"
+	}
+
+	expected := st.expected()
+	if len(expected) != 1 || expected[0] == "a digit" {
+		if format, ok := prefixErrors[st.prefix]; ok && c != 0 {
+			return fmt.Errorf(format, c, line)
+		}
+		return fmt.Errorf("invalid instruction: letter %s expected but found %s", strings.Join(expected, " or "), found)
+	}
+
+	spec := st.specs[0]
+	format := spec.mismatch
+	if format == "" {
+		format = defaultMismatch
+	}
+
+	return fmt.Errorf(format, spec.errorName(), expected[0][1], found)
+}
+
+// invalidEnding reports a complete token that is directly followed by a character that cannot follow it
+func (st *state) invalidEnding(found string) error {
+	spec := st.accept
+
+	if strings.HasSuffix(spec.pattern, "#") || strings.HasSuffix(spec.pattern, "*") {
+		return fmt.Errorf("invalid %s: whitespace or end of line expected but found %s", spec.errorName(), found)
+	}
+
+	format := spec.ending
+	if format == "" {
+		format = defaultEnding
+	}
+
+	return fmt.Errorf(format, spec.errorName(), spec.pattern, found)
+}
+
+func (spec *tokenSpec) errorName() string {
+	if spec.name != "" {
+		return spec.name
+	}
+
+	return spec.pattern
+}
@@ -1,9 +1,10 @@
 package scheduler
 
 import (
-	"container/list"
 	"fmt"
 
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
 	m "github.com/bivguy/Comp412/models"
 )
 
@@ -13,6 +14,7 @@ type DependenceGraph struct {
 	leafNodes       []*m.DependenceNode
 	maxLine         int
 	maxTotalLatency int
+	machine         *machine.Description
 }
 
 func NewDependenceNode(op *m.OperationNode) *m.DependenceNode {
@@ -29,26 +31,18 @@ func New() *DependenceGraph {
 	DGraph := make(map[int]*m.DependenceNode)
 
 	return &DependenceGraph{
-		graph:  graph,
-		DGraph: DGraph,
+		graph:   graph,
+		DGraph:  DGraph,
+		machine: machine.Default(),
 	}
 }
 
-func computeLatency(node *m.DependenceNode, edgeType m.EdgeType) int {
+func (g *DependenceGraph) computeLatency(node *m.DependenceNode, edgeType m.EdgeType) int {
 	if edgeType == m.SERIALIZATION {
 		return 1
 	}
 
-	switch node.Op.Opcode {
-	case "load", "store":
-		return 6
-	case "mult":
-		return 3
-	case "add", "sub", "lshift", "rshift", "loadI", "output", "nop":
-		return 1
-	default:
-		return 1
-	}
+	return g.machine.Latency(node.Op.Opcode)
 }
 
 // TODO: add in the reverse edges
@@ -57,9 +51,11 @@ func (g *DependenceGraph) ConnectNodes(in *m.DependenceNode, out *m.DependenceNo
 		fmt.Println("Connecting line", in.Op.Line, "to line", out.Op.Line, "with edge type", edgeType.String())
 	}
 
+	latency := g.computeLatency(in, edgeType)
+
 	// don't connect a node to another node if there's already another edge to this node of a higher latency
 	if existingEdge, exists := in.Edges[out.Op.Line]; exists {
-		if existingEdge.Latency >= computeLatency(in, edgeType) {
+		if existingEdge.Latency >= latency {
 			if DEBUG_DEPENDENCE_GRAPH {
 				fmt.Println("Edge from line", in.Op.Line, "to line", out.Op.Line, "already exists as a ", existingEdge.Type, " edge with latency ", existingEdge.Latency, " latency. Skipping ", edgeType, " connection.")
 			}
@@ -69,7 +65,7 @@ func (g *DependenceGraph) ConnectNodes(in *m.DependenceNode, out *m.DependenceNo
 	edge := &m.DependenceEdge{
 		To:      out,
 		Type:    edgeType,
-		Latency: computeLatency(in, edgeType),
+		Latency: latency,
 	}
 
 	// connect the edge where the node is defined to the node where it is used (definition -> use) by mapping the line number to the node
@@ -78,7 +74,7 @@ func (g *DependenceGraph) ConnectNodes(in *m.DependenceNode, out *m.DependenceNo
 	reverseEdge := &m.DependenceEdge{
 		To:      in,
 		Type:    edgeType,
-		Latency: computeLatency(out, edgeType),
+		Latency: g.computeLatency(out, edgeType),
 	}
 
 	// connect this to the opposite node as well
@@ -87,15 +83,14 @@ func (g *DependenceGraph) ConnectNodes(in *m.DependenceNode, out *m.DependenceNo
 	// fmt.Printf("line %d has edge to line %d \n", in.Op.Line, out.Op.Line)
 }
 
-func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.DependenceNode {
+func (g *DependenceGraph) CreateDependenceGraph(IR *ir.Block) map[int]*m.DependenceNode {
 	var mostRecentStore *m.DependenceNode
 	var mostRecentOutput *m.DependenceNode
 	var previousReads []*m.DependenceNode
+	readers := make(map[int][]*m.DependenceNode) // the operations that read each register since it was last defined
 
 	var line int
-	for node := IR.Front(); node != nil; node = node.Next() {
-
-		op := node.Value.(*m.OperationNode)
+	for _, op := range IR.Ops() {
 		opCode := op.Opcode
 
 		if opCode == "nop" {
@@ -110,23 +105,14 @@ func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.Depend
 		node := NewDependenceNode(op)
 
 		operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+		operands := m.Opcodes[opCode].Operands
 
 		g.DGraph[line] = node
 
-		// go through each operand that's defined and add the node to the graph if there is a definition
-		for i, o := range operandList {
-			// skip if its not active or if its not definiition
-			if !o.Active || !isRegister(opCode, i) || !isDefinition(opCode, i) {
-				continue
-			}
-
-			g.graph[o.VR] = node
-		}
-
 		// go through each use and add edges from uses to their definitions
 		for i, o := range operandList {
 			// skip if its not active or if its a definiition
-			if !o.Active || !isRegister(opCode, i) || isDefinition(opCode, i) {
+			if !o.Active || operands[i] != m.USE {
 				continue
 			}
 
@@ -137,10 +123,44 @@ func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.Depend
 				}
 				g.ConnectNodes(node, defNode, m.DATA)
 			}
+			readers[o.VR] = append(readers[o.VR], node)
+		}
+
+		// go through each operand that's defined and add the node to the graph if there is a definition. A register the
+		// renamer kept the same in every block may be defined again; the new definition has to wait for the reads of the
+		// old value and for the old definition to finish, which early release must not skip.
+		for i, o := range operandList {
+			// skip if its not active or if its not definiition
+			if !o.Active || operands[i] != m.DEF {
+				continue
+			}
+
+			for _, reader := range readers[o.VR] {
+				if reader != node {
+					g.ConnectNodes(node, reader, m.SERIALIZATION)
+				}
+			}
+			if defNode, exists := g.graph[o.VR]; exists {
+				g.ConnectNodes(node, defNode, m.CONFLICT)
+			}
+
+			g.graph[o.VR] = node
+			delete(readers, o.VR)
 		}
 
-		// load & output need an edge to the most recent store
-		if opCode == "load" || opCode == "output" {
+		memory := m.Opcodes[opCode]
+
+		// a branch ends the block, so it waits for every other operation in the block to finish
+		if memory.Branch {
+			for _, other := range g.DGraph {
+				if other != node {
+					g.ConnectNodes(node, other, m.DATA)
+				}
+			}
+		}
+
+		// loads & output need an edge to the most recent store
+		if memory.ReadsMemory || opCode == "output" {
 			if mostRecentStore != nil {
 				if DEBUG_DEPENDENCE_GRAPH {
 					fmt.Println("Connecting the load or output at line", line, "to most recent store at line", mostRecentStore.Op.Line)
@@ -159,8 +179,8 @@ func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.Depend
 			}
 		}
 
-		// store needs an edge to the most recent store, as well as each previous load & output
-		if opCode == "store" {
+		// a store needs an edge to the most recent store, as well as each previous load & output
+		if memory.WritesMemory {
 			if mostRecentStore != nil {
 				if DEBUG_DEPENDENCE_GRAPH {
 					fmt.Println("Connecting store at line", line, "to most recent store at line", mostRecentStore.Op.Line)
@@ -182,13 +202,13 @@ func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.Depend
 		}
 
 		// update the recent store/output/reads
-		switch opCode {
-		case "store":
+		switch {
+		case memory.WritesMemory:
 			mostRecentStore = node
-		case "output":
+		case opCode == "output":
 			mostRecentOutput = node
 			previousReads = append(previousReads, node)
-		case "load":
+		case memory.ReadsMemory:
 			previousReads = append(previousReads, node)
 		}
 	}
@@ -200,20 +220,3 @@ func (g *DependenceGraph) CreateDependenceGraph(IR *list.List) map[int]*m.Depend
 
 	return g.DGraph
 }
-
-func isRegister(opcode string, i int) bool {
-	if (opcode == "loadI" && i == 0) || (opcode == "output" && i == 2) {
-		return false
-	}
-
-	return true
-}
-
-func isDefinition(opcode string, i int) bool {
-	if opcode == "store" {
-		return false
-	} else if i == 2 {
-		return true
-	}
-	return false
-}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"sort"
 
 	m "github.com/bivguy/Comp412/models"
 )
@@ -19,6 +20,19 @@ func computePriority(graph *DependenceGraph) {
 		fmt.Println("About to do DFS on node of opCode ", startNode.Op.Opcode)
 	}
 	graph.dfs(startNode, 0, m.DATA, seen)
+
+	// every other operation that nothing depends on starts a DFS of its own, so operations that the last one does not
+	// depend on are scheduled too
+	var lines []int
+	for line, node := range graph.DGraph {
+		if line != graph.maxLine && len(node.ReverseEdges) == 0 {
+			lines = append(lines, line)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lines)))
+	for _, line := range lines {
+		graph.dfs(graph.DGraph[line], 0, m.DATA, make(map[int]bool))
+	}
 }
 
 func (g *DependenceGraph) dfs(node *m.DependenceNode, incomingLatency int, edgeType m.EdgeType, seen map[int]bool) {
@@ -36,7 +50,7 @@ func (g *DependenceGraph) dfs(node *m.DependenceNode, incomingLatency int, edgeT
 		fmt.Println("doing DFS on line ", curLine, " with", len(node.Edges), " neighbors")
 	}
 	// fmt.Println("Doing DFS for line ", node.Op.Line)
-	curTotalLatency := incomingLatency + computeLatency(node, edgeType)
+	curTotalLatency := incomingLatency + g.computeLatency(node, edgeType)
 	// check if this node has already been visited (unvisited means node latency is 0)
 	if node.TotalLatency != 0 && node.TotalLatency >= curTotalLatency {
 		if DEBUG_PRIORITY_COMPUTATION {
@@ -45,13 +59,14 @@ func (g *DependenceGraph) dfs(node *m.DependenceNode, incomingLatency int, edgeT
 		return
 	}
 
-	// means this node is unvisited
+	// means this node is unvisited, or was reached by a shorter path
+	firstVisit := node.TotalLatency == 0
 	node.TotalLatency = curTotalLatency
-	node.Latency = computeLatency(node, edgeType)
+	node.Latency = g.computeLatency(node, edgeType)
 
 	// check if this is a leaf node if it has 0 outgoing edges
 	// TOOD: this reverse edge check might be wrong
-	if len(node.Edges) == 0 {
+	if len(node.Edges) == 0 && firstVisit {
 		g.leafNodes = append(g.leafNodes, node)
 		if DEBUG_PRIORITY_COMPUTATION {
 			fmt.Println("adding the leaf node of line ", node.Op.Line)
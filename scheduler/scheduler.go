@@ -2,10 +2,12 @@ package scheduler
 
 import (
 	"container/heap"
-	"container/list"
 	"fmt"
 	"strings"
 
+	"github.com/bivguy/Comp412/cfg"
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
 	m "github.com/bivguy/Comp412/models"
 )
 
@@ -20,24 +22,82 @@ const (
 	RETIRED                   // 3
 )
 
+// operationBlock holds the operation issued in each slot of a cycle, in slot order; empty slots hold a nop
 type operationBlock struct {
-	operationOne *m.DependenceNode
-	operationTwo *m.DependenceNode
+	operations []*m.DependenceNode
 }
 
 type scheduler struct {
-	IR *list.List
+	IR      *ir.Block
+	machine *machine.Description
 }
 
-func NewSchedule(IR *list.List) *scheduler {
-	return &scheduler{IR: IR}
+func NewSchedule(IR *ir.Block, machine *machine.Description) *scheduler {
+	return &scheduler{IR: IR, machine: machine}
 }
 
-func (s *scheduler) Schedule() []*operationBlock {
+// Schedule schedules each basic block of the IR on its own and returns the cycles of every block in program order. A
+// block's label moves to the first cycle of its schedule, and its branch issues in its last cycle. It fails if the
+// machine has no unit that can issue one of the operations.
+func (s *scheduler) Schedule() ([]*operationBlock, error) {
+	var schedule []*operationBlock
+
+	for _, block := range cfg.New(s.IR).Blocks {
+		blockSchedule, err := s.scheduleBlock(block.IR())
+		if err != nil {
+			return nil, err
+		}
+		if block.Label != "" {
+			blockSchedule = s.label(blockSchedule, block.Label)
+		}
+		schedule = append(schedule, blockSchedule...)
+	}
+
+	return schedule, nil
+}
+
+// label moves a block's label from the operation that carried it onto the operation in the first slot of the block's
+// first cycle, copying both so the IR is left as it was
+func (s *scheduler) label(schedule []*operationBlock, label string) []*operationBlock {
+	for _, block := range schedule {
+		for i, dn := range block.operations {
+			if dn.Op.Label == label {
+				unlabeled := *dn.Op
+				unlabeled.Label = ""
+				block.operations[i] = &m.DependenceNode{Op: &unlabeled, Status: RETIRED}
+			}
+		}
+	}
+
+	// a block of nothing but nops still needs a cycle to carry its label
+	if len(schedule) == 0 {
+		schedule = append(schedule, s.emptyBlock(&m.DependenceNode{Op: &m.OperationNode{Opcode: "nop"}, Status: RETIRED}))
+	}
+
+	labeled := *schedule[0].operations[0].Op
+	labeled.Label = label
+	schedule[0].operations[0] = &m.DependenceNode{Op: &labeled, Status: RETIRED}
+
+	return schedule
+}
+
+// emptyBlock returns a cycle with the given nop in every slot
+func (s *scheduler) emptyBlock(notOp *m.DependenceNode) *operationBlock {
+	opBlock := &operationBlock{operations: make([]*m.DependenceNode, s.machine.IssueWidth)}
+	for i := range opBlock.operations {
+		opBlock.operations[i] = notOp
+	}
+
+	return opBlock
+}
+
+// scheduleBlock schedules the operations of a single basic block
+func (s *scheduler) scheduleBlock(IR *ir.Block) ([]*operationBlock, error) {
 	var schedule []*operationBlock
 	// create a dependence graph
 	graph := New()
-	graph.CreateDependenceGraph(s.IR)
+	graph.machine = s.machine
+	graph.CreateDependenceGraph(IR)
 
 	computePriority(graph)
 
@@ -83,61 +143,23 @@ func (s *scheduler) Schedule() []*operationBlock {
 			}
 		}
 		skipped := []*m.DependenceNode{}
-		opBlock := &operationBlock{
-			operationOne: notOp,
-			operationTwo: notOp,
-		}
+		opBlock := s.emptyBlock(notOp)
 		numIssued := 0
 
-		for numIssued < 2 && len(ready) > 0 {
+		for numIssued < s.machine.IssueWidth && len(ready) > 0 {
 			dn := heap.Pop(&ready).(*m.DependenceNode)
 
-			// check where this should go in the opBlock
-			opCode := dn.Op.Opcode
-			switch opCode {
-			// only one output is allowed per cycle (in either slot)
-			case "output":
-				// check if either slot is taken
-				if opBlock.operationOne.Op.Opcode != "nop" && opBlock.operationTwo.Op.Opcode != "nop" {
-					// both slots are taken, skip this one for now
-					skipped = append(skipped, dn)
-					continue
-				}
-				opBlock.operationOne = dn
-				numIssued = 2 // force an exit
-			// mult can only go in slot two
-			case "mult":
-				if opBlock.operationTwo.Op.Opcode != "nop" {
-					skipped = append(skipped, dn)
-					continue
-				}
-				opBlock.operationTwo = dn
-				numIssued += 1
-			// load or store can only go in slot one
-			case "load", "store":
-				if opBlock.operationOne.Op.Opcode != "nop" {
-					skipped = append(skipped, dn)
-					continue
-				}
-				opBlock.operationOne = dn
-				numIssued += 1
-			// all other operations can go in either slot
-			default:
-				if opBlock.operationTwo.Op.Opcode == "nop" {
-					opBlock.operationTwo = dn
-					numIssued += 1
-				} else if opBlock.operationOne.Op.Opcode == "nop" {
-					opBlock.operationOne = dn
-					numIssued += 1
-				} else {
-					fmt.Println("ERROR: both slots taken when they shouldn't be")
-					numIssued = 2
-					continue
-				}
+			// put it in a free slot whose unit can execute it, respecting the per cycle limit of its opcode
+			slot := s.freeSlot(opBlock, dn.Op.Opcode)
+			if slot == nil {
+				skipped = append(skipped, dn)
+				continue
 			}
+			*slot = dn
+			numIssued += 1
 
 			// pick an operation from each functional unit
-			removeIndex := cycle + computeLatency(dn, m.DATA)
+			removeIndex := cycle + graph.computeLatency(dn, m.DATA)
 			if DEBUG_SCHEDULING {
 				if removeIndex <= cycle {
 					fmt.Println("ERROR: remove index ", removeIndex, " is less than or equal to current cycle ", cycle)
@@ -160,6 +182,12 @@ func (s *scheduler) Schedule() []*operationBlock {
 
 		}
 
+		// with nothing in flight, a cycle that issues nothing would be followed by the same cycle forever
+		if numIssued == 0 && len(active) == 0 {
+			dn := skipped[0]
+			return nil, fmt.Errorf("cannot schedule %s at line %d: no unit of the %s machine can issue it", dn.Op.Opcode, dn.Op.Line, s.machine.Name)
+		}
+
 		schedule = append(schedule, opBlock)
 		// push back the skipped nodes
 		for _, skippedNode := range skipped {
@@ -223,8 +251,8 @@ func (s *scheduler) Schedule() []*operationBlock {
 		for _, ops := range active {
 			for _, dn := range ops {
 				// examine each load and store
-				opCode := dn.Op.Opcode
-				if opCode == "load" || opCode == "store" {
+				memory := m.Opcodes[dn.Op.Opcode]
+				if memory.ReadsMemory || memory.WritesMemory {
 					// check ops that depend on this current operation an early release
 					for _, edge := range dn.ReverseEdges {
 						// skip all nodes that aren't connected by a serial edge
@@ -241,8 +269,8 @@ func (s *scheduler) Schedule() []*operationBlock {
 						valid := true
 						for _, candidateEdge := range candidate.Edges {
 							if candidateEdge.Type == m.SERIALIZATION {
-								// node connected via serial edge is not ready
-								if candidateEdge.To.Status == NOT_READY {
+								// node connected via serial edge has not been issued yet
+								if candidateEdge.To.Status != ACTIVE && candidateEdge.To.Status != RETIRED {
 									valid = false
 									break
 								}
@@ -268,20 +296,72 @@ func (s *scheduler) Schedule() []*operationBlock {
 	if DEBUG_SCHEDULING {
 		fmt.Println("length of scheduler: ", len(schedule))
 	}
-	return schedule
+	return schedule, nil
 }
 
-func (s *scheduler) PrintSchedule() {
-	scheduledBlocks := s.Schedule()
+// freeSlot picks the slot for an operation: the last free slot whose unit can execute it, so the earlier, more
+// specialized slots stay open for as long as possible. It returns nil if there is no such slot this cycle.
+func (s *scheduler) freeSlot(block *operationBlock, opcode string) **m.DependenceNode {
+	if limit := s.machine.PerCycle(opcode); limit > 0 {
+		issued := 0
+		for _, dn := range block.operations {
+			if dn.Op.Opcode == opcode {
+				issued++
+			}
+		}
+		if issued >= limit {
+			return nil
+		}
+	}
+
+	units := s.machine.UnitsFor(opcode)
+	for i := len(units) - 1; i >= 0; i-- {
+		slot := &block.operations[units[i]]
+		if (*slot).Op.Opcode == "nop" {
+			return slot
+		}
+	}
+
+	return nil
+}
+
+// Bundles schedules the IR and returns the operations issued in each cycle, in slot order
+func (s *scheduler) Bundles() ([][]*m.OperationNode, error) {
+	schedule, err := s.Schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	var bundles [][]*m.OperationNode
+	for _, block := range schedule {
+		bundle := make([]*m.OperationNode, len(block.operations))
+		for i, dn := range block.operations {
+			bundle[i] = dn.Op
+		}
+		bundles = append(bundles, bundle)
+	}
+
+	return bundles, nil
+}
+
+func (s *scheduler) PrintSchedule() error {
+	scheduledBlocks, err := s.Schedule()
+	if err != nil {
+		return err
+	}
 	var b strings.Builder
 
 	for _, block := range scheduledBlocks {
 		// build each block
 
-		opString := "[ " + block.operationOne.Op.String() + " ; " + block.operationTwo.Op.String() + " ]\n"
+		ops := make([]string, len(block.operations))
+		for i, dn := range block.operations {
+			ops[i] = dn.Op.String()
+		}
 
-		fmt.Fprintf(&b, opString)
+		fmt.Fprintf(&b, "[ %s ]\n", strings.Join(ops, " ; "))
 	}
 
 	fmt.Println(b.String())
+	return nil
 }
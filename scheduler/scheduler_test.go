@@ -1,15 +1,15 @@
 package scheduler
 
 import (
-	"container/list"
 	"testing"
 
+	"github.com/bivguy/Comp412/ir"
 	m "github.com/bivguy/Comp412/models"
 )
 
 type TestCase struct {
 	description string
-	IR          *list.List
+	IR          *ir.Block
 }
 
 type PriorityTestCase struct {
@@ -21,7 +21,7 @@ type PriorityTestCase struct {
 type SchedulerTestCase struct {
 	description string
 	graph       *DependenceGraph
-	expected    *list.List
+	expected    *ir.Block
 }
 
 func createOperand(VR int) m.Operand {
@@ -54,11 +54,11 @@ func makeGraph(nodes ...*m.DependenceNode) *DependenceGraph {
 var simpleTestCases = []TestCase{
 	// {
 	// 	description: "simple dependence garph test",
-	// 	IR: func() *list.List {
-	// 		ir := list.New()
+	// 	IR: func() *ir.Block {
+	// 		IR := ir.New()
 
 	// 		// loadI 8 => r1
-	// 		ir.PushBack(&m.OperationNode{
+	// 		IR.Append(&m.OperationNode{
 	// 			Line:   1,
 	// 			Opcode: "loadI",
 	// 			OpOne: m.Operand{
@@ -71,7 +71,7 @@ var simpleTestCases = []TestCase{
 	// 		})
 
 	// 		// loadI 12 => r2
-	// 		ir.PushBack(&m.OperationNode{
+	// 		IR.Append(&m.OperationNode{
 	// 			Line:   2,
 	// 			Opcode: "loadI",
 	// 			OpOne: m.Operand{
@@ -84,7 +84,7 @@ var simpleTestCases = []TestCase{
 	// 		})
 
 	// 		// mult r1, r2 => r3
-	// 		ir.PushBack(&m.OperationNode{
+	// 		IR.Append(&m.OperationNode{
 	// 			Line:    3,
 	// 			Opcode:  "mult",
 	// 			OpOne:   createOperand(1),
@@ -93,7 +93,7 @@ var simpleTestCases = []TestCase{
 	// 		})
 
 	// 		// add r1, r3 => r4
-	// 		ir.PushBack(&m.OperationNode{
+	// 		IR.Append(&m.OperationNode{
 	// 			Line:    4,
 	// 			Opcode:  "add",
 	// 			OpOne:   createOperand(1),
@@ -101,16 +101,16 @@ var simpleTestCases = []TestCase{
 	// 			OpThree: createOperand(4),
 	// 		})
 
-	// 		return ir
+	// 		return IR
 	// 	}(),
 	// },
 	{
 		description: "memory + output dependence graph test",
-		IR: func() *list.List {
-			ir := list.New()
+		IR: func() *ir.Block {
+			IR := ir.New()
 
 			// 1: loadI 8 => r3
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    1,
 				Opcode:  "loadI",
 				OpOne:   m.Operand{SR: 8, Active: true, VR: -1, NU: -1},
@@ -118,7 +118,7 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 2: loadI 12 => r4
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    2,
 				Opcode:  "loadI",
 				OpOne:   m.Operand{SR: 12, Active: true, VR: -1, NU: -1},
@@ -126,7 +126,7 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 3: add r3, r4 => r0
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    3,
 				Opcode:  "add",
 				OpOne:   createOperand(3),
@@ -135,7 +135,7 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 4: load r0 => r1
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    4,
 				Opcode:  "load",
 				OpOne:   createOperand(0),
@@ -143,7 +143,7 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 5: load r3 => r2
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    5,
 				Opcode:  "load",
 				OpOne:   createOperand(3),
@@ -151,7 +151,7 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 6: store r1 => r0
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:    6,
 				Opcode:  "store",
 				OpOne:   createOperand(1), // value
@@ -159,13 +159,13 @@ var simpleTestCases = []TestCase{
 			})
 
 			// 7: output 12
-			ir.PushBack(&m.OperationNode{
+			IR.Append(&m.OperationNode{
 				Line:   7,
 				Opcode: "output",
 				OpOne:  m.Operand{SR: 12, Active: true, VR: -1, NU: -1},
 			})
 
-			return ir
+			return IR
 		}(),
 	},
 }
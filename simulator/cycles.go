@@ -0,0 +1,122 @@
+package simulator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bivguy/Comp412/machine"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Hazard records an operation that read a register or memory word before the operation producing it had finished
+type Hazard struct {
+	Cycle      int
+	Op         *m.OperationNode
+	Location   string
+	ReadyCycle int
+}
+
+func (h Hazard) String() string {
+	return fmt.Sprintf("cycle %d: line %d (%s) reads %s before it is ready at cycle %d", h.Cycle, h.Op.Line, h.Op.Opcode, h.Location, h.ReadyCycle)
+}
+
+// CycleReport summarizes a cycle by cycle run of a schedule
+type CycleReport struct {
+	Instructions int // number of bundles issued
+	Operations   int // number of operations issued, not counting nops
+	Cycles       int // the cycle in which the last result is written
+	Hazards      []Hazard
+}
+
+func (r *CycleReport) String() string {
+	return fmt.Sprintf("Executed %d instructions and %d operations in %d cycles.", r.Instructions, r.Operations, r.Cycles)
+}
+
+// RunSchedule issues one bundle per cycle. Every operation in a bundle reads its operands when it issues, and its result
+// is written once its latency on the machine has passed; reading a value before then is recorded as a hazard and sees
+// the old value. A branch that is taken makes the bundle with its label the next one to issue.
+func (s *simulator) RunSchedule(schedule [][]*m.OperationNode, machine *machine.Description) (*CycleReport, error) {
+	report := &CycleReport{}
+	pending := make(map[int][]effect)
+
+	labels := make(map[string]int)
+	for i, bundle := range schedule {
+		for _, op := range bundle {
+			if op.Label != "" {
+				labels[op.Label] = i
+			}
+		}
+	}
+
+	for i := 0; i < len(schedule); {
+		bundle := schedule[i]
+		next := i + 1
+
+		report.Instructions++
+		if report.Instructions > MAXOPERATIONS {
+			report.Hazards = s.hazards
+			return report, s.simulatorError(bundle[0], fmt.Errorf("stopped after %d instructions; the program may not end", MAXOPERATIONS))
+		}
+		s.cycle = report.Instructions
+		s.retire(pending)
+
+		for _, op := range bundle {
+			if op.Opcode != "nop" {
+				report.Operations++
+			}
+
+			result, err := s.evaluate(op)
+			if err != nil {
+				report.Hazards = s.hazards
+				return report, err
+			}
+
+			done := s.cycle + machine.Latency(op.Opcode)
+			report.Cycles = max(report.Cycles, done-1)
+			if result == nil {
+				continue
+			}
+			if result.branch {
+				target, ok := labels[result.target]
+				if !ok {
+					report.Hazards = s.hazards
+					return report, s.simulatorError(op, fmt.Errorf("branch to undefined label %s", result.target))
+				}
+				next = target
+				continue
+			}
+
+			pending[done] = append(pending[done], *result)
+			if result.memory {
+				s.memoryReady[result.location] = done
+			} else {
+				s.registerReady[result.location] = done
+			}
+		}
+
+		i = next
+	}
+
+	// drain the results that are still in flight after the last bundle
+	cycles := make([]int, 0, len(pending))
+	for cycle := range pending {
+		cycles = append(cycles, cycle)
+	}
+	sort.Ints(cycles)
+	for _, cycle := range cycles {
+		s.cycle = cycle
+		s.retire(pending)
+	}
+
+	report.Hazards = s.hazards
+
+	return report, nil
+}
+
+// retire writes every result that becomes available in the current cycle
+func (s *simulator) retire(pending map[int][]effect) {
+	for _, result := range pending[s.cycle] {
+		s.write(result)
+	}
+	delete(pending, s.cycle)
+}
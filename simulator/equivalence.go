@@ -0,0 +1,134 @@
+package simulator
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bivguy/Comp412/allocator"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// Mismatch describes the first place where a transformed block stops behaving like the original
+type Mismatch struct {
+	Output      int // the 1-based output that differs, or 0 if every output matches
+	Address     int // the memory word that differs when every output matches
+	Original    int32
+	Transformed int32
+
+	// the output operations involved, and the operations that last wrote the word they printed (or the differing word)
+	OriginalOp        *m.OperationNode
+	TransformedOp     *m.OperationNode
+	OriginalWriter    *m.OperationNode
+	TransformedWriter *m.OperationNode
+
+	// set when one run printed fewer values than the other
+	MissingOutputs     bool
+	OriginalOutputs    int
+	TransformedOutputs int
+}
+
+func (d *Mismatch) String() string {
+	if d.MissingOutputs {
+		return fmt.Sprintf("the original printed %d outputs but the transformed code printed %d", d.OriginalOutputs, d.TransformedOutputs)
+	}
+
+	if d.Output > 0 {
+		return fmt.Sprintf("output %d differs: original %d (%s, %s) but transformed %d (%s, %s)", d.Output,
+			d.Original, describe(d.OriginalOp), describeWriter(d.OriginalWriter),
+			d.Transformed, describe(d.TransformedOp), describeWriter(d.TransformedWriter))
+	}
+
+	return fmt.Sprintf("MEM[%d] differs: original %d (%s) but transformed %d (%s)", d.Address,
+		d.Original, describeWriter(d.OriginalWriter), d.Transformed, describeWriter(d.TransformedWriter))
+}
+
+func describe(op *m.OperationNode) string {
+	if op == nil {
+		return "no operation"
+	}
+
+	return fmt.Sprintf("line %d: %s", op.Line, op.Opcode)
+}
+
+func describeWriter(op *m.OperationNode) string {
+	if op == nil {
+		return "never stored"
+	}
+
+	return "stored by " + describe(op)
+}
+
+// Compare checks two finished runs for the same outputs and the same final memory. A word in the allocator's spill area
+// is only compared if the original run wrote it, since the allocated code also keeps its spilled values there. It
+// returns nil when the runs are equivalent.
+func Compare(original *simulator, transformed *simulator) *Mismatch {
+	if len(original.outputs) != len(transformed.outputs) {
+		// still report a differing value if there is one before the shorter run ends
+		if d := compareOutputs(original, transformed); d != nil {
+			return d
+		}
+
+		return &Mismatch{MissingOutputs: true, OriginalOutputs: len(original.outputs), TransformedOutputs: len(transformed.outputs)}
+	}
+
+	if d := compareOutputs(original, transformed); d != nil {
+		return d
+	}
+
+	for _, address := range memoryAddresses(original, transformed) {
+		if original.memory[address] != transformed.memory[address] {
+			return &Mismatch{
+				Address:           address,
+				Original:          original.memory[address],
+				Transformed:       transformed.memory[address],
+				OriginalWriter:    original.writers[address],
+				TransformedWriter: transformed.writers[address],
+			}
+		}
+	}
+
+	return nil
+}
+
+func compareOutputs(original *simulator, transformed *simulator) *Mismatch {
+	for i := range min(len(original.outputs), len(transformed.outputs)) {
+		if original.outputs[i] == transformed.outputs[i] {
+			continue
+		}
+
+		return &Mismatch{
+			Output:            i + 1,
+			Original:          original.outputs[i],
+			Transformed:       transformed.outputs[i],
+			OriginalOp:        original.outputOps[i],
+			TransformedOp:     transformed.outputOps[i],
+			OriginalWriter:    original.outputFrom[i],
+			TransformedWriter: transformed.outputFrom[i],
+		}
+	}
+
+	return nil
+}
+
+// memoryAddresses returns every address written in the original run, and every address below the spill area written
+// in the transformed run, in increasing order
+func memoryAddresses(original *simulator, transformed *simulator) []int {
+	seen := make(map[int]bool)
+	var addresses []int
+
+	for address := range original.memory {
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+	for address := range transformed.memory {
+		// a word only the transformed run wrote in the spill area is one of the allocator's spills
+		if address >= allocator.RESERVEDREGISTER || seen[address] {
+			continue
+		}
+		seen[address] = true
+		addresses = append(addresses, address)
+	}
+	sort.Ints(addresses)
+
+	return addresses
+}
@@ -0,0 +1,362 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// WORDSIZE is the number of bytes in a word of simulated memory
+const WORDSIZE = 4
+
+// MAXOPERATIONS is how many operations a run may execute before the simulator gives up on a program that may not end
+const MAXOPERATIONS = 10000000
+
+// RegisterSet selects which register name of an operand (SR, VR or PR) the simulator reads and writes
+type RegisterSet int
+
+const (
+	SOURCE   RegisterSet = iota // 0; the registers written in the input block
+	VIRTUAL                     // 1; the registers assigned by the renamer
+	PHYSICAL                    // 2; the registers assigned by the allocator
+)
+
+type simulator struct {
+	registerSet RegisterSet
+	registers   map[int]int32
+	memory      map[int]int32
+	outputs     []int32
+	outputOps   []*m.OperationNode
+	outputFrom  []*m.OperationNode       // the operation that wrote the word each output printed
+	writers     map[int]*m.OperationNode // the operation that last wrote each memory word
+	out         io.Writer
+
+	// state used when running a schedule cycle by cycle
+	cycle         int
+	registerReady map[int]int
+	memoryReady   map[int]int
+	hazards       []Hazard
+}
+
+func New(registerSet RegisterSet, out io.Writer) *simulator {
+	return &simulator{
+		registerSet: registerSet,
+		registers:   make(map[int]int32),
+		memory:      make(map[int]int32),
+		writers:     make(map[int]*m.OperationNode),
+		out:         out,
+
+		registerReady: make(map[int]int),
+		memoryReady:   make(map[int]int),
+	}
+}
+
+// SetMemory writes values into consecutive words of memory starting at address, the same way sim's -i flag does
+func (s *simulator) SetMemory(address int, values []int32) error {
+	if address%WORDSIZE != 0 {
+		return fmt.Errorf("initial memory address %d is not word aligned", address)
+	}
+
+	for i, v := range values {
+		s.memory[address+i*WORDSIZE] = v
+	}
+
+	return nil
+}
+
+// Run executes the operations in order, following each branch that is taken to its label
+func (s *simulator) Run(IR *ir.Block) error {
+	ops := IR.Ops()
+
+	labels := make(map[string]int)
+	for i, op := range ops {
+		if op.Label != "" {
+			labels[op.Label] = i
+		}
+	}
+
+	executed := 0
+	for i := 0; i < len(ops); {
+		op := ops[i]
+
+		executed++
+		if executed > MAXOPERATIONS {
+			return s.simulatorError(op, fmt.Errorf("stopped after %d operations; the program may not end", MAXOPERATIONS))
+		}
+
+		result, err := s.evaluate(op)
+		if err != nil {
+			return err
+		}
+
+		if result != nil && result.branch {
+			target, ok := labels[result.target]
+			if !ok {
+				return s.simulatorError(op, fmt.Errorf("branch to undefined label %s", result.target))
+			}
+			i = target
+			continue
+		}
+
+		if result != nil {
+			s.write(*result)
+		}
+		i++
+	}
+
+	return nil
+}
+
+// Execute runs a single operation against the register file and memory
+func (s *simulator) Execute(op *m.OperationNode) error {
+	result, err := s.evaluate(op)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		s.write(*result)
+	}
+
+	return nil
+}
+
+// effect is the register or memory word written by an operation, along with the value written. A branch that is
+// taken writes nothing; its effect is the label control goes to next.
+type effect struct {
+	op       *m.OperationNode
+	location int
+	memory   bool
+	value    int32
+
+	branch bool
+	target string
+}
+
+// evaluate reads the operands of an operation and computes what it writes without writing it; output is printed right away
+func (s *simulator) evaluate(op *m.OperationNode) (*effect, error) {
+	switch op.Opcode {
+	case "nop":
+		return nil, nil
+	case "output":
+		value, err := s.readMemory(op, op.OpThree.SR)
+		if err != nil {
+			return nil, err
+		}
+		s.outputs = append(s.outputs, value)
+		s.outputOps = append(s.outputOps, op)
+		s.outputFrom = append(s.outputFrom, s.writers[op.OpThree.SR])
+		if s.out != nil {
+			fmt.Fprintln(s.out, value)
+		}
+		return nil, nil
+	case "loadI":
+		return &effect{op: op, location: s.register(op.OpThree), value: int32(op.OpOne.SR)}, nil
+	case "i2i":
+		value, err := s.operand(op, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &effect{op: op, location: s.register(op.OpThree), value: value}, nil
+	case "load", "loadAI", "loadAO":
+		// the address is OpOne plus the offset in OpTwo, if there is one
+		address, err := s.address(op, 0)
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.readMemory(op, int(address))
+		if err != nil {
+			return nil, err
+		}
+		return &effect{op: op, location: s.register(op.OpThree), value: value}, nil
+	case "store", "storeAI", "storeAO":
+		value, err := s.operand(op, 0)
+		if err != nil {
+			return nil, err
+		}
+		address, err := s.address(op, 2)
+		if err != nil {
+			return nil, err
+		}
+		if int(address)%WORDSIZE != 0 {
+			return nil, s.simulatorError(op, fmt.Errorf("store to unaligned address %d", address))
+		}
+		return &effect{op: op, location: int(address), memory: true, value: value}, nil
+	case "jumpI":
+		return &effect{op: op, branch: true, target: op.Targets[0]}, nil
+	case "cbr":
+		// a true (non-zero) condition goes to the first label
+		condition, err := s.operand(op, 0)
+		if err != nil {
+			return nil, err
+		}
+		if condition != 0 {
+			return &effect{op: op, branch: true, target: op.Targets[0]}, nil
+		}
+		return &effect{op: op, branch: true, target: op.Targets[1]}, nil
+	case "jump":
+		return nil, s.simulatorError(op, fmt.Errorf("jumps to an address in a register are not supported"))
+	}
+
+	if _, ok := m.Opcodes[op.Opcode]; ok {
+		a, err := s.operand(op, 0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := s.operand(op, 1)
+		if err != nil {
+			return nil, err
+		}
+		result, err := arithmetic(op.Opcode, a, b)
+		if err != nil {
+			return nil, s.simulatorError(op, err)
+		}
+		return &effect{op: op, location: s.register(op.OpThree), value: result}, nil
+	}
+
+	return nil, s.simulatorError(op, fmt.Errorf("unknown opcode %q", op.Opcode))
+}
+
+func (s *simulator) write(e effect) {
+	if e.branch {
+		return
+	}
+
+	if e.memory {
+		s.memory[e.location] = e.value
+		s.writers[e.location] = e.op
+	} else {
+		s.registers[e.location] = e.value
+	}
+}
+
+// Outputs returns every value printed by an output operation so far
+func (s *simulator) Outputs() []int32 {
+	return s.outputs
+}
+
+// Memory returns the current contents of memory, keyed by byte address
+func (s *simulator) Memory() map[int]int32 {
+	return s.memory
+}
+
+func arithmetic(opcode string, a int32, b int32) (int32, error) {
+	switch opcode {
+	case "add", "addI":
+		return a + b, nil
+	case "sub", "subI":
+		return a - b, nil
+	case "mult", "multI":
+		return a * b, nil
+	case "div", "divI":
+		if b == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return a / b, nil
+	// like sim, only the low five bits of the shift amount are used
+	case "lshift", "lshiftI":
+		return a << (b & 31), nil
+	case "rshift", "rshiftI":
+		return a >> (b & 31), nil
+	// comparisons produce 1 for true and 0 for false
+	case "cmp_LT":
+		return boolValue(a < b), nil
+	case "cmp_LE":
+		return boolValue(a <= b), nil
+	case "cmp_EQ":
+		return boolValue(a == b), nil
+	case "cmp_GE":
+		return boolValue(a >= b), nil
+	case "cmp_GT":
+		return boolValue(a > b), nil
+	case "cmp_NE":
+		return boolValue(a != b), nil
+	}
+
+	return 0, fmt.Errorf("unknown arithmetic opcode %q", opcode)
+}
+
+func boolValue(b bool) int32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// operand reads operand i (0 for OpOne through 2 for OpThree) of the operation: a register, a constant, or 0 if the
+// opcode does not use it
+func (s *simulator) operand(op *m.OperationNode, i int) (int32, error) {
+	o := []m.Operand{op.OpOne, op.OpTwo, op.OpThree}[i]
+
+	switch m.Opcodes[op.Opcode].Operands[i] {
+	case m.USE:
+		return s.readRegister(op, o)
+	case m.IMMEDIATE:
+		return int32(o.SR), nil
+	}
+
+	return 0, nil
+}
+
+// address computes the memory address of a load or store: the base register in operand i plus the offset in OpTwo
+func (s *simulator) address(op *m.OperationNode, i int) (int32, error) {
+	base, err := s.operand(op, i)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := s.operand(op, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return base + offset, nil
+}
+
+// register picks the register name of an operand for the register set being simulated
+func (s *simulator) register(o m.Operand) int {
+	switch s.registerSet {
+	case VIRTUAL:
+		return o.VR
+	case PHYSICAL:
+		return o.PR
+	default:
+		return o.SR
+	}
+}
+
+func (s *simulator) readRegister(op *m.OperationNode, o m.Operand) (int32, error) {
+	r := s.register(o)
+	// a register whose producer is still in flight is a hazard rather than an error, so the rest of the schedule can be checked
+	inFlight := false
+	if ready, ok := s.registerReady[r]; ok && ready > s.cycle {
+		s.hazards = append(s.hazards, Hazard{Cycle: s.cycle, Op: op, Location: fmt.Sprintf("r%d", r), ReadyCycle: ready})
+		inFlight = true
+	}
+
+	value, ok := s.registers[r]
+	if !ok && !inFlight {
+		return 0, s.simulatorError(op, fmt.Errorf("read of undefined register r%d", r))
+	}
+
+	return value, nil
+}
+
+// readMemory reads the word at address; memory that was never written holds 0
+func (s *simulator) readMemory(op *m.OperationNode, address int) (int32, error) {
+	if address%WORDSIZE != 0 {
+		return 0, s.simulatorError(op, fmt.Errorf("read from unaligned address %d", address))
+	}
+
+	if ready, ok := s.memoryReady[address]; ok && ready > s.cycle {
+		s.hazards = append(s.hazards, Hazard{Cycle: s.cycle, Op: op, Location: fmt.Sprintf("MEM[%d]", address), ReadyCycle: ready})
+	}
+
+	return s.memory[address], nil
+}
+
+func (s *simulator) simulatorError(op *m.OperationNode, err error) error {
+	return fmt.Errorf("simulator error at line %d (%s): %w", op.Line, op.Opcode, err)
+}
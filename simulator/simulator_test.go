@@ -0,0 +1,433 @@
+package simulator
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/bivguy/Comp412/ir"
+	"github.com/bivguy/Comp412/machine"
+	m "github.com/bivguy/Comp412/models"
+	p "github.com/bivguy/Comp412/parser"
+	r "github.com/bivguy/Comp412/renamer"
+	s "github.com/bivguy/Comp412/scanner"
+	sched "github.com/bivguy/Comp412/scheduler"
+)
+
+type TestCase struct {
+	description     string
+	input           string
+	memory          string
+	expectedOutputs []int32
+}
+
+type ArithmeticTestCase struct {
+	description    string
+	IR             *ir.Block
+	expectedOutput int32
+	expectedError  bool
+}
+
+type ScheduleTestCase struct {
+	description     string
+	schedule        [][]*m.OperationNode
+	expectedCycles  int
+	expectedHazards int
+}
+
+var simpleTestCases = []TestCase{
+	{
+		description:     "add two numbers",
+		input:           "../test_files/ex1.txt",
+		expectedOutputs: []int32{314},
+	},
+	{
+		description:     "shift amounts use their low five bits",
+		input:           "../test_files/cc1.txt",
+		expectedOutputs: []int32{96},
+	},
+	{
+		description:     "triangular numbers",
+		input:           "../test_files/report2.i",
+		expectedOutputs: []int32{1, 3, 6, 10, 15, 21, 28, 36, 45, 55},
+	},
+	{
+		description:     "initial memory",
+		input:           "../test_files/report16.i",
+		memory:          "-i 1024 1056 1052 1048 1044 1040 1036 1032 1028 1024",
+		expectedOutputs: []int32{1032, 1028, 1024, 1036, 1056},
+	},
+	{
+		description:     "full ILOC opcode set",
+		input:           "../test_files/full_iloc.i",
+		memory:          "-i 1024 10 20 30",
+		expectedOutputs: []int32{30, 62, 1, 0, 4, 10, 80, 1, -5, 2, 1, 8},
+	},
+	{
+		description:     "loop and branches",
+		input:           "../test_files/branches.i",
+		memory:          "-i 1024 5",
+		expectedOutputs: []int32{120, 1},
+	},
+	{
+		description:     "loop that never runs",
+		input:           "../test_files/branches.i",
+		memory:          "-i 1024 0",
+		expectedOutputs: []int32{1, 0},
+	},
+}
+
+// op builds an operation whose registers are written as source registers
+func op(opcode string, one int, two int, three int) *m.OperationNode {
+	return &m.OperationNode{
+		Opcode:  opcode,
+		OpOne:   m.Operand{SR: one, Active: true},
+		OpTwo:   m.Operand{SR: two, Active: true},
+		OpThree: m.Operand{SR: three, Active: true},
+	}
+}
+
+var arithmeticTestCases = []ArithmeticTestCase{
+	{
+		description: "sub and mult",
+		IR: func() *ir.Block {
+			IR := ir.New()
+			IR.Append(op("loadI", 7, 0, 1))
+			IR.Append(op("loadI", 3, 0, 2))
+			IR.Append(op("sub", 1, 2, 3))
+			IR.Append(op("mult", 3, 1, 4))
+			IR.Append(op("loadI", 0, 0, 5))
+			IR.Append(op("store", 4, 0, 5))
+			IR.Append(op("output", 0, 0, 0))
+			return IR
+		}(),
+		expectedOutput: 28,
+	},
+	{
+		description: "immediate forms and comparisons",
+		IR: func() *ir.Block {
+			IR := ir.New()
+			IR.Append(op("loadI", 9, 0, 1))
+			IR.Append(op("multI", 1, 4, 2))
+			IR.Append(op("divI", 2, 5, 3))
+			IR.Append(op("cmp_GT", 3, 1, 4))
+			IR.Append(op("addI", 4, 40, 5))
+			IR.Append(op("loadI", 0, 0, 6))
+			IR.Append(op("storeAI", 5, 8, 6))
+			IR.Append(op("output", 0, 0, 8))
+			return IR
+		}(),
+		expectedOutput: 40,
+	},
+	{
+		description: "division by zero",
+		IR: func() *ir.Block {
+			IR := ir.New()
+			IR.Append(op("loadI", 9, 0, 1))
+			IR.Append(op("divI", 1, 0, 2))
+			return IR
+		}(),
+		expectedError: true,
+	},
+	{
+		description: "read of an undefined register",
+		IR: func() *ir.Block {
+			IR := ir.New()
+			IR.Append(op("add", 1, 2, 3))
+			return IR
+		}(),
+		expectedError: true,
+	},
+	{
+		description: "store to an unaligned address",
+		IR: func() *ir.Block {
+			IR := ir.New()
+			IR.Append(op("loadI", 6, 0, 1))
+			IR.Append(op("store", 1, 0, 1))
+			return IR
+		}(),
+		expectedError: true,
+	},
+}
+
+var scheduleTestCases = []ScheduleTestCase{
+	{
+		description: "load result used after its latency",
+		schedule: [][]*m.OperationNode{
+			{op("loadI", 0, 0, 1), op("loadI", 4, 0, 2)},
+			{op("load", 1, 0, 3), op("nop", 0, 0, 0)},
+			{op("nop", 0, 0, 0), op("nop", 0, 0, 0)},
+			{op("nop", 0, 0, 0), op("nop", 0, 0, 0)},
+			{op("nop", 0, 0, 0), op("nop", 0, 0, 0)},
+			{op("nop", 0, 0, 0), op("nop", 0, 0, 0)},
+			{op("nop", 0, 0, 0), op("nop", 0, 0, 0)},
+			{op("store", 3, 0, 2), op("nop", 0, 0, 0)},
+		},
+		expectedCycles:  13,
+		expectedHazards: 0,
+	},
+	{
+		description: "load result used too early",
+		schedule: [][]*m.OperationNode{
+			{op("loadI", 0, 0, 1), op("loadI", 4, 0, 2)},
+			{op("load", 1, 0, 3), op("loadI", 1, 0, 4)},
+			{op("nop", 0, 0, 0), op("add", 3, 4, 5)},
+			{op("store", 3, 0, 2), op("mult", 4, 4, 6)},
+		},
+		expectedCycles:  9,
+		expectedHazards: 2,
+	},
+}
+
+func TestSimpleSimulatorTestCases(t *testing.T) {
+	for _, tc := range simpleTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			runTest(tc, t)
+		})
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	for _, tc := range arithmeticTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			sim := New(SOURCE, nil)
+			err := sim.Run(tc.IR)
+			if tc.expectedError {
+				if err == nil {
+					t.Errorf("expected an error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			outputs := sim.Outputs()
+			if len(outputs) != 1 || outputs[0] != tc.expectedOutput {
+				t.Errorf("expected output %d but got %v", tc.expectedOutput, outputs)
+			}
+		})
+	}
+}
+
+func runTest(tc TestCase, t *testing.T) {
+	file, err := os.Open(tc.input)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	parser := p.New(s.New(file))
+	IR, _, err := parser.Parse()
+	if err != nil || parser.ErrorFound {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	sim := New(SOURCE, nil)
+	address, values, err := m.ParseSimInput(tc.memory)
+	if err != nil {
+		t.Fatalf("Unexpected input error: %v", err)
+	}
+	if err := sim.SetMemory(address, values); err != nil {
+		t.Fatalf("Unexpected memory error: %v", err)
+	}
+
+	if err := sim.Run(IR); err != nil {
+		t.Fatalf("Unexpected simulator error: %v", err)
+	}
+
+	if !slices.Equal(tc.expectedOutputs, sim.Outputs()) {
+		t.Errorf("expected outputs %v but got %v", tc.expectedOutputs, sim.Outputs())
+	}
+}
+
+func TestRunSchedule(t *testing.T) {
+	for _, tc := range scheduleTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			sim := New(SOURCE, nil)
+			report, err := sim.RunSchedule(tc.schedule, machine.Default())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if report.Cycles != tc.expectedCycles {
+				t.Errorf("expected %d cycles but got %d", tc.expectedCycles, report.Cycles)
+			}
+			if len(report.Hazards) != tc.expectedHazards {
+				t.Errorf("expected %d hazards but got %v", tc.expectedHazards, report.Hazards)
+			}
+		})
+	}
+}
+
+func TestScheduledBlocks(t *testing.T) {
+	for _, tc := range simpleTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			file, err := os.Open(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to open file: %v", err)
+			}
+			defer file.Close()
+
+			parser := p.New(s.New(file))
+			IR, _, err := parser.Parse()
+			if err != nil || parser.ErrorFound {
+				t.Fatalf("Unexpected parse error: %v", err)
+			}
+			renamedIR := r.New(parser.GetLargestRegister(), IR).Rename()
+
+			sim := New(VIRTUAL, nil)
+			address, values, _ := m.ParseSimInput(tc.memory)
+			sim.SetMemory(address, values)
+
+			bundles, err := sched.NewSchedule(renamedIR, machine.Default()).Bundles()
+			if err != nil {
+				t.Fatalf("Unexpected scheduler error: %v", err)
+			}
+
+			report, err := sim.RunSchedule(bundles, machine.Default())
+			if err != nil {
+				t.Fatalf("Unexpected simulator error: %v", err)
+			}
+			if len(report.Hazards) > 0 {
+				t.Errorf("schedule has hazards: %v", report.Hazards)
+			}
+			if !slices.Equal(tc.expectedOutputs, sim.Outputs()) {
+				t.Errorf("expected outputs %v but got %v", tc.expectedOutputs, sim.Outputs())
+			}
+		})
+	}
+}
+
+type CompareTestCase struct {
+	description     string
+	original        *ir.Block
+	transformed     *ir.Block
+	expectedOutput  int
+	expectedAddress int // the differing memory word, if set
+	expectedMatch   bool
+}
+
+// block builds a straight-line block from its operations, numbering their lines
+func block(ops ...*m.OperationNode) *ir.Block {
+	IR := ir.New()
+	for i, op := range ops {
+		op.Line = i + 1
+		IR.Append(op)
+	}
+	return IR
+}
+
+var compareTestCases = []CompareTestCase{
+	{
+		description: "spill area is ignored",
+		original: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 0, 0, 2),
+			op("store", 1, 0, 2),
+			op("output", 0, 0, 0),
+		),
+		transformed: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 32768, 0, 3),
+			op("store", 1, 0, 3),
+			op("loadI", 0, 0, 2),
+			op("store", 1, 0, 2),
+			op("output", 0, 0, 0),
+		),
+		expectedMatch: true,
+	},
+	{
+		description: "different output",
+		original: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 0, 0, 2),
+			op("store", 1, 0, 2),
+			op("output", 0, 0, 0),
+		),
+		transformed: block(
+			op("loadI", 6, 0, 1),
+			op("loadI", 0, 0, 2),
+			op("store", 1, 0, 2),
+			op("output", 0, 0, 0),
+		),
+		expectedOutput: 1,
+	},
+	{
+		description: "different memory",
+		original: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 8, 0, 2),
+			op("store", 1, 0, 2),
+		),
+		transformed: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 12, 0, 2),
+			op("store", 1, 0, 2),
+		),
+	},
+	{
+		description: "a word the original writes in the spill area",
+		original: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 32772, 0, 2),
+			op("store", 1, 0, 2),
+		),
+		transformed: block(
+			op("loadI", 6, 0, 1),
+			op("loadI", 32772, 0, 2),
+			op("store", 1, 0, 2),
+		),
+		expectedAddress: 32772,
+	},
+	{
+		description: "a spill over a word the original writes",
+		original: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 32768, 0, 2),
+			op("store", 1, 0, 2),
+		),
+		transformed: block(
+			op("loadI", 5, 0, 1),
+			op("loadI", 32768, 0, 2),
+			op("store", 1, 0, 2),
+			op("loadI", 7, 0, 3),
+			op("store", 3, 0, 2),
+		),
+		expectedAddress: 32768,
+	},
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range compareTestCases {
+		t.Run(tc.description, func(t *testing.T) {
+			original := New(SOURCE, nil)
+			transformed := New(SOURCE, nil)
+			if err := original.Run(tc.original); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := transformed.Run(tc.transformed); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			mismatch := Compare(original, transformed)
+			if tc.expectedMatch {
+				if mismatch != nil {
+					t.Errorf("expected the blocks to match but got %v", mismatch)
+				}
+				return
+			}
+
+			if mismatch == nil {
+				t.Fatalf("expected a mismatch but the blocks matched")
+			}
+			if mismatch.Output != tc.expectedOutput {
+				t.Errorf("expected the mismatch at output %d but got %v", tc.expectedOutput, mismatch)
+			}
+			if tc.expectedAddress != 0 && mismatch.Address != tc.expectedAddress {
+				t.Errorf("expected the mismatch at MEM[%d] but got %v", tc.expectedAddress, mismatch)
+			}
+			t.Logf("%v", mismatch)
+		})
+	}
+}
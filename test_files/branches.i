@@ -0,0 +1,26 @@
+//NAME: branches
+//SIM INPUT: -i 1024 5
+//OUTPUT: 120 1
+//
+// Computes n! for the n at 1024 in a loop, then
+// prints 1 if the result is even and 0 if it is odd.
+	loadI  1024     => r1
+	load   r1       => r2     // n
+	loadI  1        => r3     // the product so far
+	loadI  0        => r4
+L1:	cmp_GT r2, r4   => r5
+	cbr    r5       -> L2, L3
+L2:	mult   r3, r2   => r3
+	subI   r2, 1    => r2
+	jumpI           -> L1
+L3:	store  r3       => r1
+	output 1024
+	rshiftI r3, 1   => r6
+	lshiftI r6, 1   => r6
+	cmp_EQ r6, r3   => r7
+	cbr    r7       -> L4, L5
+L4:	loadI  1        => r8
+	jumpI           -> L6
+L5:	loadI  0        => r8
+L6:	store  r8       => r1
+	output 1024
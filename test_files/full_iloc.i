@@ -0,0 +1,56 @@
+//NAME: full ILOC opcode set
+//SIM INPUT: -i 1024 10 20 30
+//OUTPUT: 30 62 1 0 4 10 80 1 -5 2 1 8
+//
+// Uses every opcode beyond the Lab 1 subset: the
+// address-immediate and address-offset loads and
+// stores, the immediate arithmetic forms, div, i2i
+// and the comparisons.
+//
+loadI 1024 => r0
+loadAI r0, 4 => r1          // r1 = 20
+loadI 8 => r2
+loadAO r0, r2 => r3         // r3 = 30
+storeAI r3 => r0, 12
+output 1036
+addI r1, 12 => r5           // r5 = 32
+add r5, r3 => r6            // r6 = 62
+storeAO r6 => r0, r2
+output 1032
+cmp_LT r1, r3 => r7         // 20 < 30
+storeAI r7 => r0, 16
+output 1040
+cmp_GE r1, r3 => r8         // 20 >= 30
+storeAI r8 => r0, 20
+output 1044
+divI r1, 5 => r9            // r9 = 4
+storeAI r9 => r0, 24
+output 1048
+loadAI r0, 0 => r10         // r10 = 10
+i2i r10 => r11
+storeAI r11 => r0, 28
+output 1052
+multI r11, 8 => r12         // r12 = 80
+storeAI r12 => r0, 32
+output 1056
+cmp_NE r12, r10 => r13      // 80 != 10
+storeAI r13 => r0, 36
+output 1060
+subI r11, 15 => r14         // r14 = -5
+storeAI r14 => r0, 40
+output 1064
+rshiftI r12, 5 => r15       // r15 = 2
+storeAI r15 => r0, 44
+output 1068
+div r12, r10 => r16         // r16 = 8
+lshiftI r16, 2 => r17       // r17 = 32
+cmp_EQ r17, r5 => r18       // 32 == 32
+cmp_LE r14, r10 => r19      // -5 <= 10
+cmp_GT r14, r10 => r20      // -5 > 10
+add r18, r20 => r21         // 1
+storeAI r21 => r0, 48
+output 1072
+lshiftI r19, 3 => r22       // 8
+mult r22, r21 => r23
+storeAI r23 => r0, 52
+output 1076
@@ -0,0 +1,152 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/bivguy/Comp412/cfg"
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+)
+
+// ErrInvalid is returned by a strict validator that found problems in the block
+var ErrInvalid = errors.New("the block failed validation")
+
+// DefaultMaxRegister is the largest register number a block may use without a warning. The renamer allocates tables
+// with one entry per register number up to the largest one, so r2000000000 would cost gigabytes.
+const DefaultMaxRegister = 1 << 16
+
+type validator struct {
+	IR *ir.Block
+
+	// Strict reports every problem as an error instead of a warning
+	Strict bool
+	// MaxRegister is the largest register number that is not reported
+	MaxRegister int
+
+	diagnostics []m.Diagnostic
+}
+
+func New(IR *ir.Block) *validator {
+	return &validator{IR: IR, MaxRegister: DefaultMaxRegister}
+}
+
+// Validate checks the parsed block for problems the parser does not look for: registers read before any operation
+// could have written them, loadI and output constants that do not fit in 32 bits, and register numbers larger than
+// MaxRegister. The diagnostics are in line order. The error is ErrInvalid if the validator is strict and found any.
+func (v *validator) Validate() ([]m.Diagnostic, error) {
+	v.diagnostics = nil
+
+	blocks := cfg.New(v.IR).Blocks
+	defined := mayBeDefined(blocks)
+
+	// each register is only reported once, where it is first read undefined or first used
+	undefined := make(map[int]bool)
+	large := make(map[int]bool)
+
+	for _, block := range blocks {
+		written := make(map[int]bool)
+		for sr := range defined[block] {
+			written[sr] = true
+		}
+
+		for _, op := range block.Ops {
+			operandList := []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree}
+
+			for i, o := range operandList {
+				if !o.Active {
+					continue
+				}
+
+				if !m.IsRegister(op.Opcode, i) {
+					if (op.Opcode == "loadI" || op.Opcode == "output") && o.SR > math.MaxInt32 {
+						v.report(op, o, m.CodeConstantRange, fmt.Sprintf("the constant %d of %s does not fit in 32 bits", o.SR, op.Opcode))
+					}
+					continue
+				}
+
+				if o.SR > v.MaxRegister && !large[o.SR] {
+					large[o.SR] = true
+					v.report(op, o, m.CodeRegisterTooLarge, fmt.Sprintf("r%d is larger than the largest register allowed, r%d", o.SR, v.MaxRegister))
+				}
+
+				if !m.IsDefinition(op.Opcode, i) && !written[o.SR] && !undefined[o.SR] {
+					undefined[o.SR] = true
+					v.report(op, o, m.CodeUndefinedRead, fmt.Sprintf("r%d is read before any operation writes it", o.SR))
+				}
+			}
+
+			// an operation reads its operands before it writes its result
+			for i, o := range operandList {
+				if o.Active && m.IsRegister(op.Opcode, i) && m.IsDefinition(op.Opcode, i) {
+					written[o.SR] = true
+				}
+			}
+		}
+	}
+
+	if v.Strict && len(v.diagnostics) > 0 {
+		return v.diagnostics, ErrInvalid
+	}
+
+	return v.diagnostics, nil
+}
+
+// report records a diagnostic pointing at the operand, as a warning unless the validator is strict
+func (v *validator) report(op *m.OperationNode, o *m.Operand, code string, message string) {
+	severity := m.WARNING
+	if v.Strict {
+		severity = m.ERROR
+	}
+
+	d := m.Diagnostic{Severity: severity, Line: op.Line, Code: code, Message: message, Source: op.Source}
+	if o.StartColumn > 0 {
+		d.Column = o.StartColumn
+		d.Span = o.EndColumn - o.StartColumn + 1
+	}
+
+	v.diagnostics = append(v.diagnostics, d)
+}
+
+// mayBeDefined finds the registers that some path from the entry block writes before reaching each block. A register
+// missing from a block's set is undefined on every path into it.
+func mayBeDefined(blocks []*cfg.Block) map[*cfg.Block]map[int]bool {
+	in := make(map[*cfg.Block]map[int]bool)
+	out := make(map[*cfg.Block]map[int]bool)
+	for _, block := range blocks {
+		in[block] = make(map[int]bool)
+		out[block] = make(map[int]bool)
+	}
+
+	// the sets only grow, so this stops once a pass over the blocks changes nothing
+	for changed := true; changed; {
+		changed = false
+
+		for _, block := range blocks {
+			for _, pred := range block.Preds {
+				for sr := range out[pred] {
+					in[block][sr] = true
+				}
+			}
+
+			before := len(out[block])
+			for sr := range in[block] {
+				out[block][sr] = true
+			}
+			for _, op := range block.Ops {
+				for i, o := range []*m.Operand{&op.OpOne, &op.OpTwo, &op.OpThree} {
+					if o.Active && m.IsRegister(op.Opcode, i) && m.IsDefinition(op.Opcode, i) {
+						out[block][o.SR] = true
+					}
+				}
+			}
+
+			if len(out[block]) != before {
+				changed = true
+			}
+		}
+	}
+
+	return in
+}
@@ -0,0 +1,145 @@
+package validate
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bivguy/Comp412/ir"
+	m "github.com/bivguy/Comp412/models"
+	p "github.com/bivguy/Comp412/parser"
+	s "github.com/bivguy/Comp412/scanner"
+)
+
+// TestCase validates a block and checks where each diagnostic points and which code it has
+type TestCase struct {
+	description string
+	input       string
+	maxRegister int            // the validator's MaxRegister, if set
+	expected    []m.Diagnostic // only the Line, Column, Span and Code of each are compared
+	rendered    string         // how the first diagnostic renders as a warning, if set
+}
+
+var testCases = []TestCase{
+	{
+		description: "valid block",
+		input:       "loadI 4 => r1\nload r1 => r2\nadd r1, r2 => r1\nstore r1 => r2\noutput 4\n",
+	},
+	{
+		description: "undefined reads",
+		input:       "loadI 4 => r1\nadd r1, r2 => r3\nstore r3 => r2\nsub r1, r2 => r2\n",
+		expected:    []m.Diagnostic{{Line: 2, Column: 9, Span: 2, Code: m.CodeUndefinedRead}},
+		rendered:    "WARNING 2:9: r2 is read before any operation writes it [W501]\n    add r1, r2 => r3\n            ^^\n",
+	},
+	{
+		description: "a register read and written by one operation",
+		input:       "addI r1, 1 => r1\n",
+		expected:    []m.Diagnostic{{Line: 1, Column: 6, Span: 2, Code: m.CodeUndefinedRead}},
+	},
+	{
+		description: "written on one path into a loop",
+		input:       "loadI 0 => r1\nL1: addI r1, 1 => r1\ncbr r1 -> L1, L2\nL2: output 0\n",
+	},
+	{
+		description: "only written after the branch that reads it",
+		input:       "L1: cbr r1 -> L2, L3\nL2: loadI 1 => r1\njumpI -> L1\nL3: nop\n",
+	},
+	{
+		description: "only written on a path that cannot reach the read",
+		input:       "loadI 1 => r1\ncbr r1 -> L1, L2\nL1: loadI 2 => r2\njumpI -> L1\nL2: store r1 => r2\n",
+		expected:    []m.Diagnostic{{Line: 5, Column: 17, Span: 2, Code: m.CodeUndefinedRead}},
+	},
+	{
+		description: "constants that do not fit in 32 bits",
+		input:       "loadI 2147483647 => r1\nloadI 2147483648 => r2\noutput 4294967296\naddI r1, 4294967296 => r3\n",
+		expected: []m.Diagnostic{
+			{Line: 2, Column: 7, Span: 10, Code: m.CodeConstantRange},
+			{Line: 3, Column: 8, Span: 10, Code: m.CodeConstantRange},
+		},
+	},
+	{
+		description: "large registers are reported once",
+		input:       "loadI 1 => r65536\nloadI 2 => r65537\nadd r65537, r65537 => r65537\n",
+		expected:    []m.Diagnostic{{Line: 2, Column: 12, Span: 6, Code: m.CodeRegisterTooLarge}},
+	},
+	{
+		description: "a lower register limit",
+		input:       "loadI 1 => r3\nload r3 => r5\n",
+		maxRegister: 4,
+		expected:    []m.Diagnostic{{Line: 2, Column: 12, Span: 2, Code: m.CodeRegisterTooLarge}},
+	},
+	{
+		description: "report2",
+		input:       "../test_files/report2.i",
+	},
+	{
+		description: "t2",
+		input:       "../test_files/t2.i.txt",
+		expected: []m.Diagnostic{
+			{Line: 11, Column: 15, Span: 2, Code: m.CodeUndefinedRead},
+			{Line: 14, Column: 13, Span: 2, Code: m.CodeUndefinedRead},
+			{Line: 15, Column: 11, Span: 2, Code: m.CodeUndefinedRead},
+		},
+	},
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				validator := New(parse(t, tc.input))
+				validator.Strict = strict
+				if tc.maxRegister > 0 {
+					validator.MaxRegister = tc.maxRegister
+				}
+
+				diagnostics, err := validator.Validate()
+				if (err == ErrInvalid) != (strict && len(tc.expected) > 0) || (err != nil && err != ErrInvalid) {
+					t.Fatalf("strict %t: unexpected error: %v", strict, err)
+				}
+
+				severity := m.WARNING
+				if strict {
+					severity = m.ERROR
+				}
+
+				var actual []m.Diagnostic
+				for _, d := range diagnostics {
+					if d.Severity != severity {
+						t.Errorf("strict %t: expected a %v but got %+v", strict, severity, d)
+					}
+					actual = append(actual, m.Diagnostic{Line: d.Line, Column: d.Column, Span: d.Span, Code: d.Code})
+				}
+				if !reflect.DeepEqual(tc.expected, actual) {
+					t.Errorf("strict %t: expected diagnostics %+v but got %+v", strict, tc.expected, actual)
+				}
+
+				if !strict && tc.rendered != "" && len(diagnostics) > 0 && diagnostics[0].Render() != tc.rendered {
+					t.Errorf("expected the first diagnostic to render as\n%s\nbut got\n%s", tc.rendered, diagnostics[0].Render())
+				}
+			}
+		})
+	}
+}
+
+// parse parses the input from a file when it names one, and otherwise parses the input itself
+func parse(t *testing.T, input string) *ir.Block {
+	var reader io.Reader = strings.NewReader(input)
+	if strings.HasPrefix(input, "../") {
+		file, err := os.Open(input)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	IR, _, err := p.New(s.New(reader)).Parse()
+	if err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+
+	return IR
+}